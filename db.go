@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 	_ "github.com/go-sql-driver/mysql" //MySQL library package for SQL
 )
 
@@ -14,8 +15,30 @@ import (
 //DB ...
 type DB struct {
 	*sql.DB
-	Query  Query
+	Tx      *sql.Tx
+	Dialect Dialect
+	Naming  NamingStrategy
+	Query   Query
+
+	//Errors is no longer written, errors are recorded per builder chain on Query.Errors.
+	//
+	//Deprecated: use the error returned by the chain's terminal method.
 	Errors []error
+
+	//DryRun makes terminal methods record their rendered statements in
+	//Statements instead of preparing and executing them.
+	DryRun     bool
+	Statements []Statement
+
+	//Timestamps configures the fields set automatically on insert and update, and Clock
+	//the time they are set to, defaulting to time.Now.
+	Timestamps Timestamps
+	Clock      func() time.Time
+
+	//Retry re-runs transactions failing with a deadlock or serialization failure.
+	Retry RetryPolicy
+
+	types *typeRegistry
 }
 
 //Connect establishes a new database connection
@@ -24,29 +47,36 @@ func Connect(dialect string, username string, password string, host string, port
 	if dialect == "" || username == "" || host == "" || port == "" || database == "" {
 		return nil, errors.New("Missing database credentials")
 	}
-	worm = &DB{}
-	worm.DB, err = sql.Open(dialect, fmt.Sprintf("%s:%s@tcp(%s:%s)/%s", username, password, host, port, database))
+	worm = &DB{Dialect: dialectFor(dialect)}
+	worm.ResetQuery()
+	//clientFoundRows makes MySQL report the rows an UPDATE matched rather than changed, which Save relies on
+	worm.DB, err = sql.Open(dialect, fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?clientFoundRows=true", username, password, host, port, database))
 
 	return
 }
 
-//HasErrors ...
+//session returns a handle sharing the connection, transaction and settings of db, with a query of its own.
+func (db *DB) session() *DB {
+	session := &DB{DB: db.DB, Tx: db.Tx, Dialect: db.Dialect, Naming: db.Naming, DryRun: db.DryRun, Timestamps: db.Timestamps, Clock: db.Clock, Retry: db.Retry, types: db.types}
+	session.ResetQuery()
+
+	return session
+}
+
+//HasErrors reports whether the current builder chain recorded an error.
 func (db *DB) HasErrors() bool {
-	return len(db.Errors) > 0
+	return len(db.Query.Errors) > 0
 }
 
-//ErrorMessages ...
+//ErrorMessages joins the errors recorded by the current builder chain.
 func (db *DB) ErrorMessages() error {
-	var msg string
-	for _, err := range db.Errors {
-		msg += err.Error() + "\n"
-	}
-
-	return errors.New(msg)
+	return errors.Join(db.Query.Errors...)
 }
 
+//Error records an error of the current builder chain, it is returned by the chain's terminal
+//method and cleared along with the rest of the query.
 func (db *DB) Error(err error) {
 	if err != nil {
-		db.Errors = append(db.Errors, err)
+		db.Query.Errors = append(db.Query.Errors, err)
 	}
 }
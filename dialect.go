@@ -0,0 +1,321 @@
+package cworm
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+//Dialect describes how a database driver differs in the SQL and values it expects.
+type Dialect interface {
+	Name() string
+	ParseTime(value string) (time.Time, error)
+	JSONExtract(column string, path []string) (string, []interface{})
+	Rebind(query string) string
+	SupportsReturning() bool
+	MaxPlaceholders() int
+	InsertIds(res sql.Result, rows int) ([]int64, error)
+	Upsert(insert string, conflict []string, update []string) string
+	ClassifyError(err error) error
+}
+
+//dialectFor returns the Dialect for a database/sql driver name, defaulting to MySQL.
+func dialectFor(driver string) Dialect {
+	switch strings.ToLower(driver) {
+	case "postgres", "pgx":
+		return postgresDialect{}
+	case "sqlite", "sqlite3":
+		return sqliteDialect{}
+	}
+
+	return mysqlDialect{}
+}
+
+//parseTime tries each layout in turn. RFC3339 is always tried first as it is how
+//database/sql renders time.Time values returned by the driver into raw bytes.
+func parseTime(value string, layouts ...string) (time.Time, error) {
+	for _, layout := range append([]string{time.RFC3339Nano}, layouts...) {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("Cannot parse %q as time", value)
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string {
+	return "mysql"
+}
+
+func (mysqlDialect) ParseTime(value string) (time.Time, error) {
+	if strings.HasPrefix(value, "0000-00-00") {
+		return time.Time{}, nil
+	}
+
+	return parseTime(value, "2006-01-02 15:04:05.999999", "2006-01-02", "15:04:05")
+}
+
+func (mysqlDialect) Rebind(query string) string {
+	return query
+}
+
+func (mysqlDialect) SupportsReturning() bool {
+	return false
+}
+
+func (mysqlDialect) MaxPlaceholders() int {
+	return 65535
+}
+
+//InsertIds counts up from LAST_INSERT_ID(), which is the id of the first row of a multi-row insert.
+func (mysqlDialect) InsertIds(res sql.Result, rows int) ([]int64, error) {
+	first, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, rows)
+	for i := range ids {
+		ids[i] = first + int64(i)
+	}
+
+	return ids, nil
+}
+
+//Upsert uses ON DUPLICATE KEY UPDATE, or INSERT IGNORE when there is nothing to update.
+func (mysqlDialect) Upsert(insert string, conflict []string, update []string) string {
+	if len(update) == 0 {
+		return strings.Replace(insert, "INSERT INTO", "INSERT IGNORE INTO", 1)
+	}
+
+	var set []string
+	for _, column := range update {
+		set = append(set, column+"=VALUES("+column+")")
+	}
+
+	return insert + " ON DUPLICATE KEY UPDATE " + strings.Join(set, ",")
+}
+
+//ClassifyError returns the kind of a server error by its number, or of a lost connection.
+func (mysqlDialect) ClassifyError(err error) error {
+	if errors.Is(err, mysql.ErrInvalidConn) {
+		return ErrConnection
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return nil
+	}
+
+	switch mysqlErr.Number {
+	case 1062, 1586:
+		return ErrUniqueViolation
+	case 1216, 1217, 1451, 1452:
+		return ErrForeignKeyViolation
+	case 1213:
+		return ErrDeadlock
+	case 2006, 2013:
+		return ErrConnection
+	}
+
+	return nil
+}
+
+//JSONExtract reads the path with JSON_EXTRACT, bound as a jsonPath.
+func (mysqlDialect) JSONExtract(column string, path []string) (string, []interface{}) {
+	return "JSON_EXTRACT(" + column + ", ?)", []interface{}{jsonPath(path)}
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string {
+	return "postgres"
+}
+
+func (postgresDialect) ParseTime(value string) (time.Time, error) {
+	return parseTime(value, "2006-01-02 15:04:05.999999-07", "2006-01-02 15:04:05.999999-07:00", "2006-01-02 15:04:05.999999", "2006-01-02")
+}
+
+//Rebind replaces ? placeholders with $1, $2... skipping quoted strings.
+func (postgresDialect) Rebind(query string) string {
+	var rebound strings.Builder
+	quote, n := rune(0), 0
+
+	for _, chr := range query {
+		switch {
+		case quote != 0:
+			if chr == quote {
+				quote = 0
+			}
+		case chr == '\'' || chr == '"':
+			quote = chr
+		case chr == '?':
+			n++
+			rebound.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		rebound.WriteRune(chr)
+	}
+
+	return rebound.String()
+}
+
+func (postgresDialect) SupportsReturning() bool {
+	return true
+}
+
+func (postgresDialect) MaxPlaceholders() int {
+	return 65535
+}
+
+//InsertIds is not supported, ids are read back with RETURNING instead.
+func (postgresDialect) InsertIds(res sql.Result, rows int) ([]int64, error) {
+	return nil, nil
+}
+
+func (postgresDialect) Upsert(insert string, conflict []string, update []string) string {
+	return onConflictUpsert(insert, conflict, update)
+}
+
+//ClassifyError returns the kind of an error by its SQLSTATE, as reported by both lib/pq and pgx errors.
+func (postgresDialect) ClassifyError(err error) error {
+	var pgErr interface{ SQLState() string }
+	if !errors.As(err, &pgErr) {
+		return nil
+	}
+
+	switch state := pgErr.SQLState(); {
+	case state == "23505":
+		return ErrUniqueViolation
+	case state == "23503":
+		return ErrForeignKeyViolation
+	case state == "40P01":
+		return ErrDeadlock
+	case state == "40001":
+		return ErrSerialization
+	case strings.HasPrefix(state, "08"):
+		return ErrConnection
+	}
+
+	return nil
+}
+
+//JSONExtract reads the path with the #>> operator, bound as a text[] literal of quoted keys.
+func (postgresDialect) JSONExtract(column string, path []string) (string, []interface{}) {
+	if len(path) == 0 {
+		return column, nil
+	}
+
+	keys := make([]string, len(path))
+	for i, key := range path {
+		keys[i] = `"` + jsonKeyEscaper.Replace(key) + `"`
+	}
+
+	return column + " #>> CAST(? AS text[])", []interface{}{"{" + strings.Join(keys, ",") + "}"}
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string {
+	return "sqlite3"
+}
+
+func (sqliteDialect) ParseTime(value string) (time.Time, error) {
+	return parseTime(value, "2006-01-02 15:04:05.999999999-07:00", "2006-01-02T15:04:05.999999999", "2006-01-02 15:04:05.999999999", "2006-01-02")
+}
+
+func (sqliteDialect) Rebind(query string) string {
+	return query
+}
+
+func (sqliteDialect) SupportsReturning() bool {
+	return false
+}
+
+func (sqliteDialect) MaxPlaceholders() int {
+	return 999
+}
+
+//InsertIds counts back from last_insert_rowid(), which is the id of the last row of a multi-row insert.
+func (sqliteDialect) InsertIds(res sql.Result, rows int) ([]int64, error) {
+	last, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, rows)
+	for i := range ids {
+		ids[i] = last - int64(rows-1-i)
+	}
+
+	return ids, nil
+}
+
+func (sqliteDialect) Upsert(insert string, conflict []string, update []string) string {
+	return onConflictUpsert(insert, conflict, update)
+}
+
+//ClassifyError returns the kind of an error by its message, as SQLite drivers don't share an error type.
+//A locked database is reported as a deadlock, as it is retried the same way.
+func (sqliteDialect) ClassifyError(err error) error {
+	message := err.Error()
+
+	switch {
+	case strings.Contains(message, "UNIQUE constraint failed"):
+		return ErrUniqueViolation
+	case strings.Contains(message, "FOREIGN KEY constraint failed"):
+		return ErrForeignKeyViolation
+	case strings.Contains(message, "database is locked"), strings.Contains(message, "database table is locked"):
+		return ErrDeadlock
+	case strings.Contains(message, "unable to open database file"):
+		return ErrConnection
+	}
+
+	return nil
+}
+
+//JSONExtract reads the path with json_extract, bound as a jsonPath.
+func (sqliteDialect) JSONExtract(column string, path []string) (string, []interface{}) {
+	return "json_extract(" + column + ", ?)", []interface{}{jsonPath(path)}
+}
+
+//onConflictUpsert renders the ON CONFLICT clause shared by Postgres and SQLite.
+func onConflictUpsert(insert string, conflict []string, update []string) string {
+	insert += " ON CONFLICT"
+	if len(conflict) > 0 {
+		insert += " (" + strings.Join(conflict, ",") + ")"
+	}
+
+	if len(update) == 0 {
+		return insert + " DO NOTHING"
+	}
+
+	var set []string
+	for _, column := range update {
+		set = append(set, column+"=EXCLUDED."+column)
+	}
+
+	return insert + " DO UPDATE SET " + strings.Join(set, ",")
+}
+
+//jsonKeyEscaper escapes a key for a double quoted JSON path or text[] element.
+var jsonKeyEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+//jsonPath renders keys as a MySQL/SQLite JSON path, e.g. $."seo"."title", quoting each key
+//so that it can't be read as path syntax. Dialects bind paths as placeholders rather than
+//writing them into the SQL, as keys often come from request input.
+func jsonPath(path []string) string {
+	rendered := "$"
+	for _, key := range path {
+		rendered += `."` + jsonKeyEscaper.Replace(key) + `"`
+	}
+
+	return rendered
+}
@@ -0,0 +1,26 @@
+package cworm
+
+import "testing"
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "SELECT * FROM posts", want: "SELECT * FROM posts"},
+		{query: "SELECT * FROM posts WHERE id=? AND views>?", want: "SELECT * FROM posts WHERE id=$1 AND views>$2"},
+		{query: "SELECT * FROM posts WHERE title='?' AND id=?", want: "SELECT * FROM posts WHERE title='?' AND id=$1"},
+		{query: `SELECT "a?b" FROM posts WHERE id=?`, want: `SELECT "a?b" FROM posts WHERE id=$1`},
+	}
+
+	for _, test := range tests {
+		if got := (postgresDialect{}).Rebind(test.query); got != test.want {
+			t.Errorf("Rebind(%q) = %q, want %q", test.query, got, test.want)
+		}
+		for _, dialect := range []Dialect{mysqlDialect{}, sqliteDialect{}} {
+			if got := dialect.Rebind(test.query); got != test.query {
+				t.Errorf("%s Rebind(%q) = %q, want it unchanged", dialect.Name(), test.query, got)
+			}
+		}
+	}
+}
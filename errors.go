@@ -0,0 +1,66 @@
+package cworm
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+)
+
+//Errors returned by terminal methods, to be checked with errors.Is.
+var (
+	ErrNotFound       = errors.New("Not found")
+	ErrNoRowsAffected = errors.New("No rows updated")
+	ErrNotAStruct     = errors.New("Model given is not a struct")
+
+	//ErrStaleObject is returned by Save when the model's row was changed since it was loaded, as
+	//its `cworm:"version"` column no longer holds the version the model was read with.
+	ErrStaleObject = errors.New("Model was changed since it was loaded")
+)
+
+//Driver errors are wrapped with one of these kinds, keeping the driver's error available to errors.As.
+//
+//	if errors.Is(err, cworm.ErrUniqueViolation) { ... }
+//
+//	var mysqlErr *mysql.MySQLError
+//	if errors.As(err, &mysqlErr) { ... }
+var (
+	ErrUniqueViolation     = errors.New("Unique constraint violation")
+	ErrForeignKeyViolation = errors.New("Foreign key constraint violation")
+	ErrDeadlock            = errors.New("Deadlock")
+	ErrSerialization       = errors.New("Serialization failure")
+	ErrConnection          = errors.New("Connection error")
+)
+
+var errorKinds = []error{ErrUniqueViolation, ErrForeignKeyViolation, ErrDeadlock, ErrSerialization, ErrConnection}
+
+//classify wraps a driver error with its kind, leaving any other error as it is.
+func (db *DB) classify(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	for _, kind := range errorKinds {
+		if errors.Is(err, kind) {
+			return err
+		}
+	}
+
+	kind := db.dialect().ClassifyError(err)
+	if kind == nil && isConnectionError(err) {
+		kind = ErrConnection
+	}
+	if kind == nil {
+		return err
+	}
+
+	return fmt.Errorf("%w: %w", kind, err)
+}
+
+//isConnectionError matches the connection errors shared by every driver.
+func isConnectionError(err error) bool {
+	var netErr net.Error
+
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) || errors.As(err, &netErr)
+}
@@ -0,0 +1,50 @@
+package cworm
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+//pgError reports a SQLSTATE the way lib/pq and pgx errors do.
+type pgError string
+
+func (err pgError) Error() string {
+	return "pq: " + string(err)
+}
+
+func (err pgError) SQLState() string {
+	return string(err)
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		err     error
+		want    error
+	}{
+		{dialect: mysqlDialect{}, err: &mysql.MySQLError{Number: 1062}, want: ErrUniqueViolation},
+		{dialect: mysqlDialect{}, err: &mysql.MySQLError{Number: 1452}, want: ErrForeignKeyViolation},
+		{dialect: mysqlDialect{}, err: &mysql.MySQLError{Number: 1213}, want: ErrDeadlock},
+		{dialect: mysqlDialect{}, err: fmt.Errorf("exec: %w", mysql.ErrInvalidConn), want: ErrConnection},
+		{dialect: mysqlDialect{}, err: &mysql.MySQLError{Number: 1064}, want: nil},
+		{dialect: postgresDialect{}, err: pgError("23505"), want: ErrUniqueViolation},
+		{dialect: postgresDialect{}, err: pgError("23503"), want: ErrForeignKeyViolation},
+		{dialect: postgresDialect{}, err: pgError("40P01"), want: ErrDeadlock},
+		{dialect: postgresDialect{}, err: pgError("40001"), want: ErrSerialization},
+		{dialect: postgresDialect{}, err: pgError("08006"), want: ErrConnection},
+		{dialect: postgresDialect{}, err: errors.New("syntax error"), want: nil},
+		{dialect: sqliteDialect{}, err: errors.New("UNIQUE constraint failed: posts.title"), want: ErrUniqueViolation},
+		{dialect: sqliteDialect{}, err: errors.New("FOREIGN KEY constraint failed"), want: ErrForeignKeyViolation},
+		{dialect: sqliteDialect{}, err: errors.New("database is locked"), want: ErrDeadlock},
+		{dialect: sqliteDialect{}, err: errors.New("no such table: posts"), want: nil},
+	}
+
+	for _, test := range tests {
+		if got := test.dialect.ClassifyError(test.err); got != test.want {
+			t.Errorf("%s ClassifyError(%v) = %v, want %v", test.dialect.Name(), test.err, got, test.want)
+		}
+	}
+}
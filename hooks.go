@@ -0,0 +1,140 @@
+package cworm
+
+import "reflect"
+
+//Lifecycle hooks are called on models implementing them. The tx handle runs queries in the
+//same transaction as the write, which is rolled back when a hook returns an error.
+//
+//	func (post *Post) BeforeCreate(tx *cworm.DB) error {
+//		post.Slug = slugify(post.Title)
+//		return nil
+//	}
+//
+//Insert calls BeforeSave, BeforeCreate, AfterCreate and AfterSave, Save calls BeforeSave and
+//AfterSave, Delete calls BeforeDelete and AfterDelete, and AfterFind is called on every
+//model read by Get, First and Find.
+type (
+	BeforeCreateHook interface{ BeforeCreate(tx *DB) error }
+	AfterCreateHook  interface{ AfterCreate(tx *DB) error }
+	BeforeSaveHook   interface{ BeforeSave(tx *DB) error }
+	AfterSaveHook    interface{ AfterSave(tx *DB) error }
+	BeforeDeleteHook interface{ BeforeDelete(tx *DB) error }
+	AfterDeleteHook  interface{ AfterDelete(tx *DB) error }
+	AfterFindHook    interface{ AfterFind(tx *DB) error }
+)
+
+var hookTypes = []reflect.Type{
+	reflect.TypeOf((*BeforeCreateHook)(nil)).Elem(),
+	reflect.TypeOf((*AfterCreateHook)(nil)).Elem(),
+	reflect.TypeOf((*BeforeSaveHook)(nil)).Elem(),
+	reflect.TypeOf((*AfterSaveHook)(nil)).Elem(),
+	reflect.TypeOf((*BeforeDeleteHook)(nil)).Elem(),
+	reflect.TypeOf((*AfterDeleteHook)(nil)).Elem(),
+}
+
+//hook calls one of the lifecycle hooks on a model, if it implements it.
+type hook func(model interface{}, tx *DB) error
+
+func beforeCreate(model interface{}, tx *DB) error {
+	if h, ok := model.(BeforeCreateHook); ok {
+		return h.BeforeCreate(tx)
+	}
+	return nil
+}
+
+func afterCreate(model interface{}, tx *DB) error {
+	if h, ok := model.(AfterCreateHook); ok {
+		return h.AfterCreate(tx)
+	}
+	return nil
+}
+
+func beforeSave(model interface{}, tx *DB) error {
+	if h, ok := model.(BeforeSaveHook); ok {
+		return h.BeforeSave(tx)
+	}
+	return nil
+}
+
+func afterSave(model interface{}, tx *DB) error {
+	if h, ok := model.(AfterSaveHook); ok {
+		return h.AfterSave(tx)
+	}
+	return nil
+}
+
+func beforeDelete(model interface{}, tx *DB) error {
+	if h, ok := model.(BeforeDeleteHook); ok {
+		return h.BeforeDelete(tx)
+	}
+	return nil
+}
+
+func afterDelete(model interface{}, tx *DB) error {
+	if h, ok := model.(AfterDeleteHook); ok {
+		return h.AfterDelete(tx)
+	}
+	return nil
+}
+
+func afterFind(model interface{}, tx *DB) error {
+	if h, ok := model.(AfterFindHook); ok {
+		return h.AfterFind(tx)
+	}
+	return nil
+}
+
+//runHooks calls the hooks in order on a model, by pointer when it can so pointer receivers are
+//called and changes are kept, stopping at the first error.
+func (db *DB) runHooks(model reflect.Value, hooks ...hook) error {
+	target := model.Interface()
+	if model.CanAddr() {
+		target = model.Addr().Interface()
+	}
+
+	for _, hook := range hooks {
+		if err := hook(target, db.session()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//hasWriteHooks reports whether a model implements any of the hooks called around writes.
+func hasWriteHooks(Model interface{}) bool {
+	modelType := reflect.TypeOf(Model)
+	if modelType == nil {
+		return false
+	}
+	if modelType.Kind() != reflect.Ptr {
+		modelType = reflect.PtrTo(modelType)
+	}
+
+	for _, hookType := range hookTypes {
+		if modelType.Implements(hookType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//hookTransaction runs a write of a model with hooks inside a transaction, carrying over the
+//query built so far, so an error returned by an After hook rolls the write back.
+func (db *DB) hookTransaction(fn func(tx *DB) error) error {
+	query := db.Query
+	db.ResetQuery()
+
+	return db.Transaction(func(tx *DB) error {
+		tx.Query = query
+		tx.Query.db = tx
+
+		return fn(tx)
+	})
+}
+
+//needsHookTransaction reports whether a write of the model must open a transaction for its hooks.
+func (db *DB) needsHookTransaction(Model interface{}) bool {
+	return db.Tx == nil && !db.DryRun && hasWriteHooks(Model)
+}
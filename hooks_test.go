@@ -0,0 +1,134 @@
+package cworm
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+//busyDriver opens single connections that, like MySQL's, can't run a statement while the rows
+//of another are still being read. The data source name is the number of rows every Exec affects, 1 when empty.
+type busyDriver struct{}
+
+type busyConn struct {
+	reading  bool
+	affected int64
+}
+
+type busyStmt struct {
+	conn  *busyConn
+	query string
+}
+
+type busyRows struct {
+	conn    *busyConn
+	columns []string
+	values  [][]driver.Value
+}
+
+func init() {
+	sql.Register("cworm-busy", busyDriver{})
+}
+
+func (busyDriver) Open(name string) (driver.Conn, error) {
+	if name == "" {
+		return &busyConn{affected: 1}, nil
+	}
+
+	affected, err := strconv.ParseInt(name, 10, 64)
+	return &busyConn{affected: affected}, err
+}
+
+func (conn *busyConn) Prepare(query string) (driver.Stmt, error) {
+	if conn.reading {
+		return nil, errors.New("busy buffer")
+	}
+
+	return &busyStmt{conn: conn, query: query}, nil
+}
+
+func (conn *busyConn) Close() error              { return nil }
+func (conn *busyConn) Begin() (driver.Tx, error) { return conn, nil }
+func (conn *busyConn) Commit() error             { return nil }
+func (conn *busyConn) Rollback() error           { return nil }
+
+func (stmt *busyStmt) Close() error  { return nil }
+func (stmt *busyStmt) NumInput() int { return -1 }
+
+func (stmt *busyStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(stmt.conn.affected), nil
+}
+
+func (stmt *busyStmt) Query(args []driver.Value) (driver.Rows, error) {
+	stmt.conn.reading = true
+
+	if strings.Contains(stmt.query, "COUNT(*)") {
+		return &busyRows{conn: stmt.conn, columns: []string{"count"}, values: [][]driver.Value{{int64(2)}}}, nil
+	}
+
+	return &busyRows{conn: stmt.conn, columns: []string{"id", "title"}, values: [][]driver.Value{{int64(1), "Hello"}, {int64(2), "World"}}}, nil
+}
+
+func (rows *busyRows) Columns() []string {
+	return rows.columns
+}
+
+func (rows *busyRows) Close() error {
+	rows.conn.reading = false
+	return nil
+}
+
+func (rows *busyRows) Next(dest []driver.Value) error {
+	if len(rows.values) == 0 {
+		return io.EOF
+	}
+
+	copy(dest, rows.values[0])
+	rows.values = rows.values[1:]
+
+	return nil
+}
+
+//countingPost counts the posts from its AfterFind hook, through the transaction it was loaded in.
+type countingPost struct {
+	Id    int
+	Title string
+	Count int64 `cworm:"-"`
+}
+
+func (post *countingPost) AfterFind(tx *DB) (err error) {
+	post.Count, err = tx.Count(&countingPost{})
+	return err
+}
+
+func TestAfterFindQueriesTransaction(t *testing.T) {
+	sqlDB, err := sql.Open("cworm-busy", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+
+	db := &DB{DB: sqlDB}
+
+	var posts []interface{}
+	err = db.Transaction(func(tx *DB) (err error) {
+		posts, err = tx.Get(&countingPost{})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(posts) != 2 {
+		t.Fatalf("got %d posts, want 2", len(posts))
+	}
+	for _, post := range posts {
+		if post := post.(countingPost); post.Count != 2 {
+			t.Errorf("got Count %d for post %d, want 2", post.Count, post.Id)
+		}
+	}
+}
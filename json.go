@@ -0,0 +1,85 @@
+package cworm
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+//JSON wraps a value stored in a JSON column.
+//
+//	type Post struct {
+//		Id   int
+//		Meta cworm.JSON[PostMeta]
+//	}
+type JSON[T any] struct {
+	Data T
+}
+
+//Value marshals the data for the driver.
+func (j JSON[T]) Value() (driver.Value, error) {
+	data, err := json.Marshal(j.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+//Scan unmarshals a JSON column, leaving the zero value for NULL.
+func (j *JSON[T]) Scan(value interface{}) error {
+	var data T
+
+	switch v := value.(type) {
+	case nil:
+	case []byte:
+		if err := json.Unmarshal(v, &data); err != nil {
+			return err
+		}
+	case string:
+		if err := json.Unmarshal([]byte(v), &data); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("Cannot scan %T into JSON", value)
+	}
+
+	j.Data = data
+
+	return nil
+}
+
+//jsonValue marshals a `cworm:"json"` field, writing nil pointers, maps and slices as NULL.
+func jsonValue(structField reflect.Value) (interface{}, error) {
+	switch structField.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		if structField.IsNil() {
+			return nil, nil
+		}
+	}
+
+	data, err := json.Marshal(structField.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+//scanJSON unmarshals a JSON column into a `cworm:"json"` field, resetting it for NULL.
+func scanJSON(structField reflect.Value, val []byte) error {
+	if val == nil {
+		structField.Set(reflect.Zero(structField.Type()))
+		return nil
+	}
+
+	data := reflect.New(structField.Type())
+	if err := json.Unmarshal(val, data.Interface()); err != nil {
+		return err
+	}
+
+	structField.Set(data.Elem())
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package cworm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJSONExtract(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		path    []string
+		sql     string
+		args    []interface{}
+	}{
+		{dialect: mysqlDialect{}, path: []string{"seo", "title"}, sql: "JSON_EXTRACT(meta, ?)", args: []interface{}{`$."seo"."title"`}},
+		{dialect: mysqlDialect{}, path: []string{`a') OR 1=1 --`}, sql: "JSON_EXTRACT(meta, ?)", args: []interface{}{`$."a') OR 1=1 --"`}},
+		{dialect: mysqlDialect{}, path: []string{`a"b\c`}, sql: "JSON_EXTRACT(meta, ?)", args: []interface{}{`$."a\"b\\c"`}},
+		{dialect: sqliteDialect{}, path: []string{"seo", "title"}, sql: "json_extract(meta, ?)", args: []interface{}{`$."seo"."title"`}},
+		{dialect: postgresDialect{}, path: []string{"seo", "title"}, sql: "meta #>> CAST(? AS text[])", args: []interface{}{`{"seo","title"}`}},
+		{dialect: postgresDialect{}, path: []string{`a,b}`, `c"`}, sql: "meta #>> CAST(? AS text[])", args: []interface{}{`{"a,b}","c\""}`}},
+		{dialect: postgresDialect{}, path: nil, sql: "meta", args: nil},
+	}
+
+	for _, test := range tests {
+		sql, args := test.dialect.JSONExtract("meta", test.path)
+		if sql != test.sql || !reflect.DeepEqual(args, test.args) {
+			t.Errorf("%s JSONExtract(%q) = %q %#v, want %q %#v", test.dialect.Name(), test.path, sql, args, test.sql, test.args)
+		}
+	}
+}
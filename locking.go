@@ -0,0 +1,39 @@
+package cworm
+
+import "reflect"
+
+//versionField returns the field of a model holding its optimistic locking version, if any.
+//Save fails with ErrStaleObject when the row no longer holds the version the model was read with.
+//
+//	type Post struct {
+//		Id      int
+//		Title   string
+//		Version int `cworm:"version"`
+//	}
+func versionField(modelSchema *schema) *modelField {
+	if modelSchema == nil {
+		return nil
+	}
+
+	for _, field := range modelSchema.Fields {
+		if field.Version {
+			return field
+		}
+	}
+
+	return nil
+}
+
+//nextVersion returns the version following the one held by an integer field.
+func nextVersion(structField reflect.Value) reflect.Value {
+	next := reflect.New(structField.Type()).Elem()
+
+	switch structField.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		next.SetInt(structField.Int() + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		next.SetUint(structField.Uint() + 1)
+	}
+
+	return next
+}
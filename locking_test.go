@@ -0,0 +1,84 @@
+package cworm
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type versionedPost struct {
+	Id      int
+	Title   string
+	Version int `cworm:"version"`
+}
+
+func TestVersionedStatements(t *testing.T) {
+	testStatements(t, []statementTest{
+		{
+			name: "save",
+			fn: func(db *DB) error {
+				return db.Save(&versionedPost{Id: 1, Title: "Hello", Version: 3})
+			},
+			sql: map[string]string{
+				"mysql":    "UPDATE versioned_posts SET title=?,version=? WHERE versioned_posts.id=? AND versioned_posts.version=?",
+				"postgres": "UPDATE versioned_posts SET title=$1,version=$2 WHERE versioned_posts.id=$3 AND versioned_posts.version=$4",
+				"sqlite3":  "UPDATE versioned_posts SET title=?,version=? WHERE versioned_posts.id=? AND versioned_posts.version=?",
+			},
+			args: []interface{}{"Hello", 4, 1, 3},
+		},
+		{
+			name: "update",
+			fn: func(db *DB) error {
+				return db.Update(&versionedPost{Id: 1, Version: 3}, map[string]interface{}{"title": "Hello"})
+			},
+			sql: map[string]string{
+				"mysql":    "UPDATE versioned_posts SET title=?,version=? WHERE versioned_posts.id=? AND versioned_posts.version=?",
+				"postgres": "UPDATE versioned_posts SET title=$1,version=$2 WHERE versioned_posts.id=$3 AND versioned_posts.version=$4",
+				"sqlite3":  "UPDATE versioned_posts SET title=?,version=? WHERE versioned_posts.id=? AND versioned_posts.version=?",
+			},
+			args: []interface{}{"Hello", 4, 1, 3},
+		},
+	})
+}
+
+func TestVersionedSave(t *testing.T) {
+	tests := []struct {
+		name     string
+		affected string
+		version  int
+		err      error
+	}{
+		{name: "bumps the version", affected: "1", version: 4},
+		{name: "stale", affected: "0", version: 3, err: ErrStaleObject},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sqlDB, err := sql.Open("cworm-busy", test.affected)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer sqlDB.Close()
+
+			db := &DB{DB: sqlDB}
+			post := versionedPost{Id: 1, Title: "Hello", Version: 3}
+
+			if err := db.Save(&post); !errors.Is(err, test.err) {
+				t.Fatalf("got error %v, want %v", err, test.err)
+			}
+			if post.Version != test.version {
+				t.Errorf("got Version %d, want %d", post.Version, test.version)
+			}
+		})
+	}
+}
+
+func TestNextVersion(t *testing.T) {
+	for _, version := range []interface{}{int(1), int64(1), uint(1), uint32(1)} {
+		got := nextVersion(reflect.ValueOf(version))
+		if got.Type() != reflect.TypeOf(version) || got.Convert(reflect.TypeOf(int64(0))).Int() != 2 {
+			t.Errorf("nextVersion(%#v) = %#v, want 2 of the same type", version, got.Interface())
+		}
+	}
+}
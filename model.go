@@ -0,0 +1,15 @@
+package cworm
+
+import "time"
+
+//Model holds the fields shared by most models and can be embedded to flatten them into the parent's columns.
+//
+//	type Post struct {
+//		cworm.Model
+//		Title string
+//	}
+type Model struct {
+	ID        uint `cworm:"primaryKey;autoIncrement;column:id"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
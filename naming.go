@@ -0,0 +1,29 @@
+package cworm
+
+//NamingStrategy maps Go type and field names to table and column names.
+//Implementations are used as cache keys and must be comparable.
+type NamingStrategy interface {
+	TableName(model string) string
+	ColumnName(field string) string
+}
+
+//Naming is the default NamingStrategy. Tables are the pluralized snake_case of the model
+//name, e.g. BlogCategory => blog_categories, and columns the snake_case of the field name.
+type Naming struct {
+	TablePrefix    string
+	SingularTables bool
+}
+
+//TableName ...
+func (naming Naming) TableName(model string) string {
+	if naming.SingularTables {
+		return naming.TablePrefix + snakeCase(model)
+	}
+
+	return naming.TablePrefix + pluralizeString(snakeCase(model))
+}
+
+//ColumnName ...
+func (naming Naming) ColumnName(field string) string {
+	return snakeCase(field)
+}
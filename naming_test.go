@@ -0,0 +1,68 @@
+package cworm
+
+import "testing"
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"Post":         "post",
+		"BlogCategory": "blog_category",
+		"UserID":       "user_id",
+		"ID":           "id",
+		"IDs":          "ids",
+		"UserIDs":      "user_ids",
+		"HTTPServer":   "http_server",
+		"URLsList":     "urls_list",
+		"Address2":     "address2",
+		"Version2Beta": "version2_beta",
+		"Status":       "status",
+	}
+
+	for name, want := range tests {
+		if got := snakeCase(name); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestPluralizeString(t *testing.T) {
+	tests := map[string]string{
+		"key":           "keys",
+		"day":           "days",
+		"category":      "categories",
+		"blog_category": "blog_categories",
+		"person":        "people",
+		"Person":        "People",
+		"status":        "statuses",
+		"box":           "boxes",
+		"church":        "churches",
+		"quiz":          "quizzes",
+		"leaf":          "leaves",
+		"news":          "news",
+		"user_id":       "user_ids",
+	}
+
+	for str, want := range tests {
+		if got := pluralizeString(str); got != want {
+			t.Errorf("pluralizeString(%q) = %q, want %q", str, got, want)
+		}
+	}
+}
+
+func TestNamingTableName(t *testing.T) {
+	tests := []struct {
+		naming Naming
+		model  string
+		want   string
+	}{
+		{naming: Naming{}, model: "BlogCategory", want: "blog_categories"},
+		{naming: Naming{}, model: "Person", want: "people"},
+		{naming: Naming{TablePrefix: "cw_"}, model: "Status", want: "cw_statuses"},
+		{naming: Naming{SingularTables: true}, model: "UserID", want: "user_id"},
+	}
+
+	for _, test := range tests {
+		if got := test.naming.TableName(test.model); got != test.want {
+			t.Errorf("%#v.TableName(%q) = %q, want %q", test.naming, test.model, got, test.want)
+		}
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"reflect"
-	"strconv"
+	"sort"
 	"strings"
+	"time"
 )
 
 //Query ...
@@ -31,12 +33,47 @@ type Query struct {
 	Joins      []interface{}
 
 	Model reflect.Value
+
+	//Errors recorded while building the query, returned by its terminal method.
+	Errors []error
+
+	schema     *schema
+	onConflict *onConflict
+	selected   []string
+	omitted    []string
+	updates    map[string]interface{}
+	global     bool
+	trashed    trashed
+	force      bool
+	refresh    bool
+
+	//db is the DB running the query, whose dialect, naming, types and clock it reads. DB methods
+	//set it first, as a DB built as a struct literal or copied by value hasn't been through ResetQuery.
+	db *DB
+}
+
+//onConflict configures how an insert handles rows conflicting with existing ones.
+type onConflict struct {
+	Columns   []string
+	Update    []string
+	DoNothing bool
+}
+
+//Statement is a rendered SQL statement along with its bound arguments.
+type Statement struct {
+	SQL  string
+	Args []interface{}
 }
 
 //Exists ...
 func (db *DB) Exists(Model interface{}) (exists bool, err error) {
-	db.Query.Table, err = getTableName(Model)
-	if err != nil {
+	db.Query.db = db
+
+	if db.HasErrors() {
+		return db.ReturnBool(false, db.ErrorMessages())
+	}
+
+	if err = db.Query.setModel(Model); err != nil {
 		return db.ReturnBool(false, err)
 	}
 
@@ -47,16 +84,58 @@ func (db *DB) Exists(Model interface{}) (exists bool, err error) {
 
 	sql = fmt.Sprintf("SELECT EXISTS(%s LIMIT 1)", sql)
 
-	fmt.Println(sql)
+	if db.record(sql, db.Query.Args) {
+		return db.ReturnBool(false, nil)
+	}
+
+	stmt, err := db.prepare(sql)
+	if err != nil {
+		return db.ReturnBool(false, err)
+	}
+	defer stmt.Close()
 
-	err = db.QueryRow(sql, db.Query.Args...).Scan(&exists)
+	err = stmt.QueryRow(db.Query.Args...).Scan(&exists)
 	if err != nil {
-		return db.ReturnBool(false, fmt.Errorf("Error checking if row exists %v", err))
+		return db.ReturnBool(false, fmt.Errorf("Error checking if row exists %w", err))
 	}
 
 	return db.ReturnBool(exists, nil)
 }
 
+//Count returns the number of rows of the model's table matching the conditions.
+func (db *DB) Count(Model interface{}) (count int64, err error) {
+	db.Query.db = db
+
+	if db.HasErrors() {
+		return db.ReturnInt64(0, db.ErrorMessages())
+	}
+
+	if err = db.Query.setModel(Model); err != nil {
+		return db.ReturnInt64(0, err)
+	}
+
+	sql, err := db.Select("COUNT(*)").Query.BuildSelect()
+	if err != nil {
+		return db.ReturnInt64(0, err)
+	}
+
+	if db.record(sql, db.Query.Args) {
+		return db.ReturnInt64(0, nil)
+	}
+
+	stmt, err := db.prepare(sql)
+	if err != nil {
+		return db.ReturnInt64(0, err)
+	}
+	defer stmt.Close()
+
+	if err = stmt.QueryRow(db.Query.Args...).Scan(&count); err != nil {
+		return db.ReturnInt64(0, fmt.Errorf("Error counting rows %w", err))
+	}
+
+	return db.ReturnInt64(count, nil)
+}
+
 //First ...
 func (db *DB) First(Model interface{}) error {
 	rows, err := db.Limit(1).Get(Model)
@@ -64,15 +143,46 @@ func (db *DB) First(Model interface{}) error {
 		return err
 	}
 
+	if db.DryRun {
+		return nil
+	}
+
 	if len(rows) == 0 {
-		return db.ReturnError(errors.New("Not found"))
+		return db.ReturnError(ErrNotFound)
 	}
 
 	return nil
 }
 
+//Find loads the model matching the given primary key. Composite keys are given in the order their fields are declared.
+func (db *DB) Find(Model interface{}, keys ...interface{}) error {
+	db.Query.db = db
+
+	modelStruct := reflect.Indirect(reflect.ValueOf(Model))
+	if modelStruct.Kind() != reflect.Struct {
+		return db.ReturnError(ErrNotAStruct)
+	}
+
+	modelSchema := db.Query.getSchema(modelStruct.Type())
+	if len(modelSchema.PrimaryKeys) == 0 {
+		return db.ReturnError(errors.New("Model " + modelSchema.Type.Name() + " has no primary key"))
+	}
+
+	if len(keys) != len(modelSchema.PrimaryKeys) {
+		return db.ReturnError(fmt.Errorf("Model %s has %d primary key columns, %d values given", modelSchema.Type.Name(), len(modelSchema.PrimaryKeys), len(keys)))
+	}
+
+	for i, field := range modelSchema.PrimaryKeys {
+		db.Where(modelSchema.Table+"."+field.Column, "=", keys[i])
+	}
+
+	return db.First(Model)
+}
+
 //Get ...
 func (db *DB) Get(Model interface{}) ([]interface{}, error) {
+	db.Query.db = db
+
 	if db.HasErrors() {
 		return db.ReturnGroup(nil, db.ErrorMessages())
 	}
@@ -86,15 +196,21 @@ func (db *DB) Get(Model interface{}) ([]interface{}, error) {
 		return db.ReturnGroup(nil, err)
 	}
 
-	stmt, err := db.Prepare(sql)
+	if db.record(sql, db.Query.Args) {
+		return db.ReturnGroup(nil, nil)
+	}
+
+	stmt, err := db.prepare(sql)
 	if err != nil {
 		return db.ReturnGroup(nil, err)
 	}
+	defer stmt.Close()
 
 	rows, err := stmt.Query(db.Query.Args...)
 	if err != nil {
 		return db.ReturnGroup(nil, err)
 	}
+	defer rows.Close()
 
 	results, err := db.Query.fillRows(rows)
 	if err != nil {
@@ -106,86 +222,328 @@ func (db *DB) Get(Model interface{}) ([]interface{}, error) {
 
 //Insert ...
 func (db *DB) Insert(Model interface{}) (interface{}, error) {
+	db.Query.db = db
+
 	if db.HasErrors() {
 		return db.Return(nil, db.ErrorMessages())
 	}
 
+	if db.needsHookTransaction(Model) {
+		var result interface{}
+		err := db.hookTransaction(func(tx *DB) (err error) {
+			result, err = tx.Insert(Model)
+			return err
+		})
+
+		return result, err
+	}
+
 	if err := db.Query.mapStruct(Model); err != nil {
 		return db.Return(nil, err)
 	}
 
-	sql, err := db.Query.BuildInsert()
-	if err != nil {
+	//a copy is filled when the model wasn't passed by pointer
+	model := db.Query.Model
+	if !model.CanSet() {
+		model = reflect.New(model.Type()).Elem()
+		model.Set(db.Query.Model)
+		db.Query.Model = model
+	}
+
+	if err := db.runHooks(model, beforeSave, beforeCreate); err != nil {
 		return db.Return(nil, err)
 	}
 
-	stmt, err := db.Prepare(sql)
-	if err != nil {
+	if err := db.Query.validate(model, nil); err != nil {
 		return db.Return(nil, err)
 	}
 
-	_, err = stmt.Exec(db.Query.Values...)
+	sql, args, err := db.Query.BuildInsert()
 	if err != nil {
 		return db.Return(nil, err)
 	}
 
-	return db.Return(db.Query.Model.Interface(), nil)
+	if db.record(sql, args) {
+		return db.Return(model.Interface(), nil)
+	}
+
+	if err := db.insertBatch(&db.Query, []reflect.Value{model}, sql, args); err != nil {
+		return db.Return(nil, err)
+	}
+	db.Query.takeSnapshot(model)
+
+	if err := db.runHooks(model, afterCreate, afterSave); err != nil {
+		return db.Return(nil, err)
+	}
+
+	return db.Return(model.Interface(), nil)
+}
+
+//Upsert inserts the model or, when it conflicts with an existing row on conflictColumns, updates
+//updateColumns of that row instead. With no updateColumns every inserted column apart from the
+//conflict columns and primary keys is updated. MySQL ignores conflictColumns and uses any unique key.
+func (db *DB) Upsert(Model interface{}, conflictColumns []string, updateColumns []string) (interface{}, error) {
+	db.Query.onConflict = &onConflict{Columns: conflictColumns, Update: updateColumns}
+
+	return db.Insert(Model)
+}
+
+//InsertIgnore inserts the model unless it conflicts with an existing row on conflictColumns,
+//in which case nothing is done.
+func (db *DB) InsertIgnore(Model interface{}, conflictColumns ...string) (interface{}, error) {
+	db.Query.onConflict = &onConflict{Columns: conflictColumns, DoNothing: true}
+
+	return db.Insert(Model)
+}
+
+//InsertMany inserts a slice of models using multi-row INSERT statements, split into batches
+//that stay under the dialect's placeholder limit and run inside a transaction. Generated
+//primary keys are written back into the slice where the dialect allows.
+func (db *DB) InsertMany(Models interface{}) (int64, error) {
+	db.Query.db = db
+
+	if db.HasErrors() {
+		return db.ReturnInt64(0, db.ErrorMessages())
+	}
+
+	slice := reflect.Indirect(reflect.ValueOf(Models))
+	if slice.Kind() != reflect.Slice {
+		return db.ReturnInt64(0, errors.New("Models given is not a slice"))
+	}
+
+	if slice.Len() == 0 {
+		return db.ReturnInt64(0, nil)
+	}
+
+	var models []reflect.Value
+	for i := 0; i < slice.Len(); i++ {
+		model := slice.Index(i)
+		if model.Kind() == reflect.Ptr {
+			model = model.Elem()
+		}
+		models = append(models, model)
+	}
+
+	if err := db.Query.mapStruct(models[0].Interface()); err != nil {
+		return db.ReturnInt64(0, err)
+	}
+
+	query := db.Query
+
+	var count int64
+	err := db.Transaction(func(tx *DB) error {
+		//every model is checked before the first batch runs, so a failing one inserts nothing
+		for _, model := range models {
+			if err := tx.runHooks(model, beforeSave, beforeCreate); err != nil {
+				return err
+			}
+
+			if err := query.validate(model, nil); err != nil {
+				return err
+			}
+		}
+
+		batchSize := len(models)
+		if fields := len(query.insertFields(models)); fields > 0 {
+			batchSize = tx.dialect().MaxPlaceholders() / fields
+		}
+		if batchSize < 1 {
+			batchSize = 1
+		}
+
+		for start := 0; start < len(models); start += batchSize {
+			end := start + batchSize
+			if end > len(models) {
+				end = len(models)
+			}
+			batch := models[start:end]
+
+			sql, args, err := query.buildInsert(batch)
+			if err != nil {
+				return err
+			}
+
+			if tx.record(sql, args) {
+				continue
+			}
+
+			if err := tx.insertBatch(&query, batch, sql, args); err != nil {
+				return err
+			}
+
+			for _, model := range batch {
+				if err := tx.runHooks(model, afterCreate, afterSave); err != nil {
+					return err
+				}
+			}
+
+			count += int64(len(batch))
+		}
+
+		return nil
+	})
+
+	return db.ReturnInt64(count, err)
+}
+
+//insertBatch executes a multi-row insert and back-fills the models from RETURNING or the generated ids.
+func (db *DB) insertBatch(query *Query, models []reflect.Value, sql string, args []interface{}) error {
+	stmt, err := db.prepare(sql)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if db.dialect().SupportsReturning() {
+		rows, err := stmt.Query(args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for _, model := range models {
+			err := query.fillReturning(model, rows)
+			if err == errNoRowReturned && query.onConflict != nil {
+				//the conflicting rows were skipped
+				break
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	res, err := stmt.Exec(args...)
+	if err != nil {
+		return err
+	}
+
+	return query.fillInsertIds(models, res)
 }
 
-//Delete ...
+//Delete removes the model's row. Models with a DeletedAt field are soft deleted instead,
+//setting DeletedAt and leaving the row in place, unless ForceDelete is used.
 func (db *DB) Delete(Model interface{}) (int64, error) {
+	db.Query.db = db
+
 	if db.HasErrors() {
 		return db.ReturnInt64(0, db.ErrorMessages())
 	}
 
+	if db.needsHookTransaction(Model) {
+		var count int64
+		err := db.hookTransaction(func(tx *DB) (err error) {
+			count, err = tx.Delete(Model)
+			return err
+		})
+
+		return count, err
+	}
+
 	if err := db.Query.mapStruct(Model); err != nil {
 		return db.ReturnInt64(0, err)
 	}
 
-	sql, args, err := db.Query.BuildDelete()
-	if err != nil {
+	if err := db.runHooks(db.Query.Model, beforeDelete); err != nil {
 		return db.ReturnInt64(0, err)
 	}
 
-	stmt, err := db.Prepare(sql)
+	var sql string
+	var args []interface{}
+	var err error
+	if softDelete(db.Query.schema) != nil && !db.Query.force {
+		sql, args, err = db.Query.BuildSoftDelete(false)
+	} else {
+		sql, args, err = db.Query.BuildDelete()
+	}
 	if err != nil {
 		return db.ReturnInt64(0, err)
 	}
 
-	res, err := stmt.Exec(args...)
-	if err != nil {
+	count, err := db.execAffected(sql, args)
+	if err != nil || db.DryRun {
+		return db.ReturnInt64(count, err)
+	}
+
+	if err := db.runHooks(db.Query.Model, afterDelete); err != nil {
 		return db.ReturnInt64(0, err)
 	}
 
-	count, err := res.RowsAffected()
+	return db.ReturnInt64(count, nil)
+}
+
+//ForceDelete removes the model's row even when the model is soft deleted.
+func (db *DB) ForceDelete(Model interface{}) (int64, error) {
+	db.Query.force = true
+
+	return db.Delete(Model)
+}
+
+//Restore clears the DeletedAt field of a soft deleted model, and of its row.
+func (db *DB) Restore(Model interface{}) (int64, error) {
+	db.Query.db = db
+
+	if db.HasErrors() {
+		return db.ReturnInt64(0, db.ErrorMessages())
+	}
+
+	if err := db.Query.mapStruct(Model); err != nil {
+		return db.ReturnInt64(0, err)
+	}
+
+	sql, args, err := db.Query.BuildSoftDelete(true)
 	if err != nil {
 		return db.ReturnInt64(0, err)
 	}
 
-	return db.ReturnInt64(count, nil)
+	return db.ReturnInt64(db.execAffected(sql, args))
 }
 
 //Save ...
 func (db *DB) Save(Model interface{}) error {
+	db.Query.db = db
+
 	if db.HasErrors() {
 		return db.ReturnError(db.ErrorMessages())
 	}
 
+	if db.needsHookTransaction(Model) {
+		return db.hookTransaction(func(tx *DB) error {
+			return tx.Save(Model)
+		})
+	}
+
 	if err := db.Query.mapStruct(Model); err != nil {
 		return db.ReturnError(err)
 	}
 
+	if err := db.runHooks(db.Query.Model, beforeSave); err != nil {
+		return db.ReturnError(err)
+	}
+
+	if err := db.Query.validateSave(); err != nil {
+		return db.ReturnError(err)
+	}
+
 	sql, args, err := db.Query.BuildUpdate()
+	if err == errNoChanges {
+		return db.ReturnError(nil)
+	}
 	if err != nil {
 		return db.ReturnError(err)
 	}
-	fmt.Printf("%#v\n", sql)
-	fmt.Printf("%#v\n", args)
 
-	stmt, err := db.Prepare(sql)
+	if db.record(sql, args) {
+		return db.ReturnError(nil)
+	}
+
+	stmt, err := db.prepare(sql)
 	if err != nil {
 		return db.ReturnError(err)
 	}
+	defer stmt.Close()
 
 	res, err := stmt.Exec(args...)
 	if err != nil {
@@ -198,105 +556,370 @@ func (db *DB) Save(Model interface{}) error {
 	}
 
 	if count == 0 {
-		db.ReturnError(errors.New("No rows updated"))
+		if versionField(db.Query.schema) != nil {
+			return db.ReturnError(ErrStaleObject)
+		}
+
+		//without clientFoundRows MySQL doesn't count rows left unchanged, so check the row is really missing
+		exists, err := db.rowExists()
+		if err != nil {
+			return db.ReturnError(err)
+		}
+		if !exists {
+			return db.ReturnError(ErrNoRowsAffected)
+		}
 	}
 
-	return nil
-}
+	if db.Query.updates != nil {
+		if err := db.Query.applyUpdates(); err != nil {
+			return db.ReturnError(err)
+		}
+	}
+	if version := versionField(db.Query.schema); version != nil && !db.Query.isUpdating(version) && db.Query.Model.CanSet() {
+		structField := db.Query.Model.FieldByIndex(version.Index)
+		structField.Set(nextVersion(structField))
+	}
+	db.Query.takeSnapshot(db.Query.Model)
 
-//Return – TODO: Refactor to not use return functions to reset query.
-func (db *DB) Return(resp interface{}, err error) (interface{}, error) {
-	db.ResetQuery()
-	return resp, err
+	return db.ReturnError(db.runHooks(db.Query.Model, afterSave))
 }
 
-//ReturnBool – TODO: Refactor to not use return functions to reset query.
-func (db *DB) ReturnBool(resp bool, err error) (bool, error) {
-	db.ResetQuery()
-	return resp, err
+//Increment atomically adds n to a column of the model's row, or of every row matching the
+//conditions when there are any, and returns the number of rows affected. Model can be nil
+//after Model(), e.g. db.Model(Post{}).Where("author_id", "=", 1).Increment(nil, "views", 1)
+func (db *DB) Increment(Model interface{}, column string, n interface{}) (int64, error) {
+	return db.increment(Model, column, "+", n)
 }
 
-//ReturnInt64 – TODO: Refactor to not use return functions to reset query.
-func (db *DB) ReturnInt64(resp int64, err error) (int64, error) {
-	db.ResetQuery()
-	return resp, err
+//Decrement atomically subtracts n from a column of the model's row, or of every row matching the
+//conditions when there are any, and returns the number of rows affected. Model can be nil after Model().
+func (db *DB) Decrement(Model interface{}, column string, n interface{}) (int64, error) {
+	return db.increment(Model, column, "-", n)
 }
 
-//ReturnError – TODO: Refactor to not use return functions to reset query.
-func (db *DB) ReturnError(err error) error {
-	db.ResetQuery()
-	return err
-}
+//increment ...
+func (db *DB) increment(Model interface{}, column string, operator string, n interface{}) (int64, error) {
+	db.Query.db = db
 
-//ReturnGroup – TODO: Refactor to not use return functions to reset query.
-func (db *DB) ReturnGroup(resp []interface{}, err error) ([]interface{}, error) {
-	db.ResetQuery()
-	return resp, err
-}
+	if db.HasErrors() {
+		return db.ReturnInt64(0, db.ErrorMessages())
+	}
 
-//BuildJoin ...
-func (query *Query) BuildJoin(Model interface{}) error {
-	modelStruct := reflect.TypeOf(Model)
-	field, _ := reflect.TypeOf(query.Model.Interface()).FieldByName(modelStruct.Name())
-	foreignKey := field.Tag.Get("foreign_key")
-	jsonKey := field.Tag.Get("json")
-	jsonObject := field.Tag.Get("json_object")
-	if jsonObject != "" {
-		modelStruct := reflect.Indirect(reflect.ValueOf(Model))
-		tableName := query.getTable(modelStruct)
-		var columns []string
-		for i := 0; i < modelStruct.NumField(); i++ {
-			switch modelStruct.Field(i).Kind() {
-			case
-				reflect.Struct,
-				reflect.Slice,
-				reflect.Ptr:
-				continue
-			}
+	if Model != nil || db.Query.schema == nil {
+		if err := db.Query.setModel(Model); err != nil {
+			return db.ReturnInt64(0, err)
+		}
+	}
+
+	if db.Query.refresh && len(db.Query.Conditions) > 0 {
+		return db.ReturnInt64(0, errors.New("Refresh reads back the model's row, it cannot be used with conditions"))
+	}
+
+	sql, args, err := db.Query.BuildIncrement(column, operator, n)
+	if err != nil {
+		return db.ReturnInt64(0, err)
+	}
+
+	count, err := db.execAffected(sql, args)
+	if err != nil {
+		return db.ReturnInt64(0, err)
+	}
 
-			key := snakeCase(modelStruct.Type().Field(i).Name)
-			columns = append(columns, "'"+key+"'")
-			columns = append(columns, tableName+"."+key)
+	if db.Query.refresh && !db.DryRun {
+		if err := db.refreshColumn(db.Query.fieldByColumn(column)); err != nil {
+			return db.ReturnInt64(count, err)
 		}
+	}
 
-		query.Columns = append(query.Columns, fmt.Sprintf("CONCAT('[',GROUP_CONCAT(JSON_OBJECT(%s)),']') as %s", strings.Join(columns, ","), jsonKey))
-		query.Join += fmt.Sprintf(" LEFT JOIN %s on JSON_CONTAINS(%s.%s, JSON_QUOTE(%s.%s), '$')", jsonKey, query.Table, jsonKey, jsonKey, jsonObject)
-		query.GroupBy += fmt.Sprintf(" GROUP BY %s.id", query.Table)
+	return db.ReturnInt64(count, nil)
+}
 
-		return nil
+//refreshColumn reads a column of the model's row back into its field.
+func (db *DB) refreshColumn(field *modelField) error {
+	if !db.Query.Model.CanSet() {
+		return errors.New("Model given is not a pointer, cannot refresh " + field.Name)
 	}
 
-	if err := query.mapStruct(Model); err != nil {
+	where, args, err := db.Query.primaryKey()
+	if err != nil {
 		return err
 	}
 
-	joinTable, err := getTableName(Model)
+	stmt, err := db.prepare("SELECT " + db.Query.Table + "." + field.Column + " FROM " + db.Query.Table + where)
 	if err != nil {
 		return err
 	}
+	defer stmt.Close()
 
-	query.Join += fmt.Sprintf(" LEFT JOIN %s ON %s.id=%s.%s", joinTable, joinTable, query.Table, foreignKey)
+	var value interface{}
+	if err := stmt.QueryRow(args...).Scan(&value); err != nil {
+		return err
+	}
 
-	return nil
+	return db.Query.fillColumn(field, db.Query.Model.FieldByIndex(field.Index), 0, []sql.RawBytes{rawValue(value)})
 }
 
-//BuildConditions ...
-func (query *Query) BuildConditions() error {
-	for _, condition := range query.Conditions {
-		mapStruct := reflect.TypeOf(condition)
-		switch mapStruct.Name() {
-		case "Where":
-			w := condition.(Where)
-			if !strings.Contains(w.Column, ".") && query.Table != "" {
-				w.Column = query.Table + "." + w.Column
-			}
+//rowExists reports whether a row matches the conditions of the update just run, or the model's primary key.
+func (db *DB) rowExists() (exists bool, err error) {
+	where, args, err := db.Query.whereOrPrimaryKey()
+	if err != nil {
+		return false, err
+	}
 
-			if query.Where == "" {
-				query.Where = fmt.Sprintf(" WHERE %s %s ?", w.Column, w.Operator)
-			} else {
-				query.Where += fmt.Sprintf(" AND %s %s ?", w.Column, w.Operator)
-			}
+	stmt, err := db.prepare("SELECT EXISTS(SELECT 1 FROM " + db.Query.Table + where + ")")
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRow(args...).Scan(&exists)
+
+	return exists, err
+}
+
+//UpdateColumns updates the given columns of every row of the Model() table matching the
+//conditions and returns the number of rows affected. Keys are column or field names.
+func (db *DB) UpdateColumns(values map[string]interface{}) (int64, error) {
+	db.Query.db = db
+
+	if db.HasErrors() {
+		return db.ReturnInt64(0, db.ErrorMessages())
+	}
+
+	db.Query.updates = values
+
+	sql, args, err := db.Query.BuildBulkUpdate()
+	if err != nil {
+		return db.ReturnInt64(0, err)
+	}
+
+	return db.ReturnInt64(db.execAffected(sql, args))
+}
+
+//DeleteWhere deletes every row of the Model() table matching the conditions and returns
+//the number of rows affected.
+func (db *DB) DeleteWhere() (int64, error) {
+	db.Query.db = db
+
+	if db.HasErrors() {
+		return db.ReturnInt64(0, db.ErrorMessages())
+	}
+
+	sql, args, err := db.Query.BuildBulkDelete()
+	if err != nil {
+		return db.ReturnInt64(0, err)
+	}
+
+	return db.ReturnInt64(db.execAffected(sql, args))
+}
+
+//execAffected executes a statement and returns the number of rows affected.
+func (db *DB) execAffected(sql string, args []interface{}) (int64, error) {
+	if db.record(sql, args) {
+		return 0, nil
+	}
+
+	stmt, err := db.prepare(sql)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+//Update writes only the given columns of the model's row and sets the matching fields on the model.
+//Keys are column or field names.
+func (db *DB) Update(Model interface{}, values map[string]interface{}) error {
+	db.Query.updates = values
+
+	return db.Save(Model)
+}
+
+//ToSQL runs fn in DryRun mode and returns the statements its terminal methods
+//would have executed, without touching the database. Statements recorded before it are kept.
+func (db *DB) ToSQL(fn func(db *DB) error) ([]Statement, error) {
+	dryRun, recorded := db.DryRun, db.Statements
+	db.DryRun = true
+	db.Statements = nil
+
+	err := fn(db)
+	statements := db.Statements
+
+	db.DryRun = dryRun
+	db.Statements = recorded
+
+	return statements, err
+}
+
+//prepare rebinds the statement's placeholders for the dialect and prepares it, inside the
+//current transaction if there is one.
+func (db *DB) prepare(query string) (*sql.Stmt, error) {
+	if db.Tx != nil {
+		return db.Tx.Prepare(db.dialect().Rebind(query))
+	}
+
+	return db.Prepare(db.dialect().Rebind(query))
+}
+
+//dialect ...
+func (db *DB) dialect() Dialect {
+	if db.Dialect == nil {
+		return mysqlDialect{}
+	}
+
+	return db.Dialect
+}
+
+//record stores the statement when in DryRun mode and reports whether execution should be skipped.
+func (db *DB) record(sql string, args []interface{}) bool {
+	if !db.DryRun {
+		return false
+	}
+
+	db.Statements = append(db.Statements, Statement{SQL: db.dialect().Rebind(sql), Args: args})
+
+	return true
+}
+
+//Return – TODO: Refactor to not use return functions to reset query.
+func (db *DB) Return(resp interface{}, err error) (interface{}, error) {
+	db.ResetQuery()
+	return resp, db.classify(err)
+}
+
+//ReturnBool – TODO: Refactor to not use return functions to reset query.
+func (db *DB) ReturnBool(resp bool, err error) (bool, error) {
+	db.ResetQuery()
+	return resp, db.classify(err)
+}
+
+//ReturnInt64 – TODO: Refactor to not use return functions to reset query.
+func (db *DB) ReturnInt64(resp int64, err error) (int64, error) {
+	db.ResetQuery()
+	return resp, db.classify(err)
+}
+
+//ReturnError – TODO: Refactor to not use return functions to reset query.
+func (db *DB) ReturnError(err error) error {
+	db.ResetQuery()
+	return db.classify(err)
+}
+
+//ReturnGroup – TODO: Refactor to not use return functions to reset query.
+func (db *DB) ReturnGroup(resp []interface{}, err error) ([]interface{}, error) {
+	db.ResetQuery()
+	return resp, db.classify(err)
+}
+
+//BuildJoin ...
+func (query *Query) BuildJoin(Model interface{}) error {
+	if query.schema == nil {
+		return errors.New("No model to join " + reflect.TypeOf(Model).Name() + " on")
+	}
+
+	field := query.schema.RelationTo(reflect.Indirect(reflect.ValueOf(Model)).Type())
+	if field == nil {
+		return errors.New("Model " + query.schema.Type.Name() + " has no relation to " + reflect.TypeOf(Model).Name())
+	}
+
+	return query.buildJoin(query.schema, field, Model)
+}
+
+//buildJoin joins the model held by the parent's relation field.
+func (query *Query) buildJoin(parent *schema, field *modelField, Model interface{}) error {
+	join := query.getSchema(reflect.Indirect(reflect.ValueOf(Model)).Type())
+
+	if field.JSONObject != "" {
+		var columns []string
+		for _, joinField := range join.Fields {
+			if joinField.Relation {
+				continue
+			}
+
+			columns = append(columns, "'"+joinField.Column+"'")
+			columns = append(columns, join.Table+"."+joinField.Column)
+		}
+
+		query.Columns = append(query.Columns, fmt.Sprintf("CONCAT('[',GROUP_CONCAT(JSON_OBJECT(%s)),']') as %s", strings.Join(columns, ","), field.JSONKey))
+		query.Values = append(query.Values, nil)
+		query.Join += fmt.Sprintf(" LEFT JOIN %s on JSON_CONTAINS(%s.%s, JSON_QUOTE(%s.%s), '$')", field.JSONKey, parent.Table, field.JSONKey, field.JSONKey, field.JSONObject)
+		var groupBy []string
+		for _, key := range parent.PrimaryKeys {
+			groupBy = append(groupBy, parent.Table+"."+key.Column)
+		}
+		query.GroupBy += " GROUP BY " + strings.Join(groupBy, ",")
+
+		return nil
+	}
+
+	foreignKeys := strings.Split(field.ForeignKey, ",")
+	if field.ForeignKey == "" && len(join.PrimaryKeys) == 1 {
+		foreignKeys = []string{query.naming().ColumnName(join.Type.Name()) + "_" + join.PrimaryKeys[0].Column}
+	}
+
+	if len(join.PrimaryKeys) == 0 || len(foreignKeys) != len(join.PrimaryKeys) {
+		return fmt.Errorf("Relation %s needs a foreign_key for each of the %d primary key columns of %s", field.Name, len(join.PrimaryKeys), join.Table)
+	}
+
+	if err := query.mapColumns(join, reflect.Indirect(reflect.ValueOf(Model))); err != nil {
+		return err
+	}
+
+	var on []string
+	for i, key := range join.PrimaryKeys {
+		on = append(on, fmt.Sprintf("%s.%s=%s.%s", join.Table, key.Column, parent.Table, strings.TrimSpace(foreignKeys[i])))
+	}
+
+	//joined models only ever hide their soft deleted rows, OnlyTrashed applies to the queried model
+	if query.trashed != withTrashed {
+		if condition := trashedCondition(join, withoutTrashed); condition != "" {
+			on = append(on, condition)
+		}
+	}
+
+	query.Join += fmt.Sprintf(" LEFT JOIN %s ON %s", join.Table, strings.Join(on, " AND "))
+
+	return nil
+}
+
+//BuildConditions ...
+func (query *Query) BuildConditions() error {
+	for _, condition := range query.Conditions {
+		mapStruct := reflect.TypeOf(condition)
+		switch mapStruct.Name() {
+		case "Where":
+			w := condition.(Where)
+			if !strings.Contains(w.Column, ".") && query.Table != "" {
+				w.Column = query.Table + "." + w.Column
+			}
+
+			if query.Where == "" {
+				query.Where = fmt.Sprintf(" WHERE %s %s ?", w.Column, w.Operator)
+			} else {
+				query.Where += fmt.Sprintf(" AND %s %s ?", w.Column, w.Operator)
+			}
 
+			query.Args = append(query.Args, w.Value)
+		case "WhereJSON":
+			w := condition.(WhereJSON)
+			if !strings.Contains(w.Column, ".") && query.Table != "" {
+				w.Column = query.Table + "." + w.Column
+			}
+
+			extract, pathArgs := query.dialect().JSONExtract(w.Column, w.Path)
+			if query.Where == "" {
+				query.Where = fmt.Sprintf(" WHERE %s %s ?", extract, w.Operator)
+			} else {
+				query.Where += fmt.Sprintf(" AND %s %s ?", extract, w.Operator)
+			}
+
+			query.Args = append(query.Args, pathArgs...)
 			query.Args = append(query.Args, w.Value)
 		}
 	}
@@ -304,83 +927,519 @@ func (query *Query) BuildConditions() error {
 	return nil
 }
 
-//BuildSelect ...
-func (query *Query) BuildSelect() (sql string, err error) {
-	if err = query.BuildConditions(); err != nil {
-		return "", err
+//BuildSelect ...
+func (query *Query) BuildSelect() (sql string, err error) {
+	if err = query.BuildConditions(); err != nil {
+		return "", err
+	}
+	query.scopeTrashed()
+
+	if query.Select != "" {
+		sql = query.Select
+	} else {
+		sql = "SELECT " + query.getColumns()
+	}
+
+	sql += " FROM " + query.Table
+
+	if query.Join != "" {
+		sql += query.Join
+	}
+	if query.Where != "" {
+		sql += query.Where
+	}
+	if query.GroupBy != "" {
+		sql += query.GroupBy
+	}
+	if query.Having != "" {
+		sql += query.Having
+	}
+	if query.OrderBy != "" {
+		sql += query.OrderBy
+	}
+	if query.Offset != "" {
+		sql += query.Offset
+	}
+	if query.Limit != "" {
+		sql += query.Limit
+	}
+
+	return
+}
+
+//BuildInsert ...
+func (query *Query) BuildInsert() (sql string, args []interface{}, err error) {
+	if query.schema == nil {
+		return "", nil, errors.New("No model given to insert")
+	}
+
+	return query.buildInsert([]reflect.Value{query.Model})
+}
+
+//insertFields returns the fields written when inserting the models. Auto increment
+//columns are left out unless one of the models sets them.
+func (query *Query) insertFields(models []reflect.Value) (fields []*modelField) {
+	for _, field := range query.schema.Fields {
+		if field.Relation || field.Readonly {
+			continue
+		}
+
+		if field.AutoIncrement {
+			isSet := false
+			for _, model := range models {
+				isSet = isSet || !model.FieldByIndex(field.Index).IsZero()
+			}
+
+			if !isSet {
+				continue
+			}
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields
+}
+
+//conflictUpdates returns the columns updated when an upsert conflicts, defaulting to every
+//inserted column apart from the conflict columns, primary keys and the CreatedAt timestamp.
+func (query *Query) conflictUpdates(fields []*modelField) (columns []string) {
+	if query.onConflict.DoNothing {
+		return nil
+	}
+
+	if len(query.onConflict.Update) > 0 {
+		return query.onConflict.Update
+	}
+
+	timestamps := query.timestamps()
+	for _, field := range fields {
+		if !timestamps.Disabled && field.Name == timestamps.CreatedAt {
+			continue
+		}
+
+		isConflict := field.PrimaryKey
+		for _, column := range query.onConflict.Columns {
+			isConflict = isConflict || column == field.Column
+		}
+
+		if !isConflict {
+			columns = append(columns, field.Column)
+		}
+	}
+
+	return columns
+}
+
+//buildInsert renders a single INSERT of one or more rows.
+func (query *Query) buildInsert(models []reflect.Value) (sql string, args []interface{}, err error) {
+	now := query.now()
+	for _, model := range models {
+		query.touchInsert(model, now)
+	}
+
+	fields := query.insertFields(models)
+
+	var columns []string
+	for _, field := range fields {
+		columns = append(columns, field.Column)
+	}
+
+	var rows []string
+	for _, model := range models {
+		var params []string
+		for _, field := range fields {
+			value, err := query.columnValue(field, model.FieldByIndex(field.Index))
+			if err != nil {
+				return "", nil, err
+			}
+
+			params = append(params, "?")
+			args = append(args, value)
+		}
+
+		rows = append(rows, "("+strings.Join(params, ",")+")")
+	}
+
+	sql = fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", query.Table, strings.Join(columns, ","), strings.Join(rows, ","))
+
+	if query.onConflict != nil {
+		sql = query.dialect().Upsert(sql, query.onConflict.Columns, query.conflictUpdates(fields))
+	}
+
+	if query.dialect().SupportsReturning() {
+		sql += " RETURNING " + strings.Join(query.returningColumns(), ",")
+	}
+
+	return
+}
+
+//BuildUpdate ...
+func (query *Query) BuildUpdate() (sql string, args []interface{}, err error) {
+	if err = query.BuildConditions(); err != nil {
+		return "", nil, err
+	}
+
+	sql = fmt.Sprintf("UPDATE %s SET ", query.Table)
+
+	if query.schema == nil {
+		return "", nil, errors.New("No model given to update")
+	}
+
+	setSQL := []string{}
+	if query.updates != nil {
+		setSQL, args, err = query.buildUpdates()
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	//DeletedAt is left to Delete and Restore, so saving a copy loaded before a soft delete doesn't undo it
+	deletedAt := softDelete(query.schema)
+
+	for _, field := range query.schema.Fields {
+		if query.updates != nil {
+			break
+		}
+
+		if field.Relation || field.Readonly || field.PrimaryKey || field.AutoIncrement || field.Version || field == deletedAt || query.isTimestamp(field) || !query.isWritable(field) {
+			continue
+		}
+		value, err := query.columnValue(field, query.Model.FieldByIndex(field.Index))
+		if err != nil {
+			return "", nil, err
+		}
+
+		if !query.isChanged(query.Model, field, value) {
+			continue
+		}
+
+		setSQL = append(setSQL, field.Column+"=?")
+		args = append(args, value)
+	}
+
+	if len(setSQL) == 0 {
+		if _, ok := trackerOf(query.Model); ok && query.updates == nil {
+			return "", nil, errNoChanges
+		}
+		return "", nil, errors.New("No columns to update")
+	}
+
+	if field, value, ok := query.touchUpdate(query.Model, query.now()); ok && !query.isUpdating(field) {
+		setSQL = append(setSQL, field.Column+"=?")
+		args = append(args, value)
+	}
+
+	version := versionField(query.schema)
+	if version != nil && !query.isUpdating(version) {
+		setSQL = append(setSQL, version.Column+"=?")
+		args = append(args, nextVersion(query.Model.FieldByIndex(version.Index)).Interface())
+	}
+	sql += strings.Join(setSQL, ",")
+
+	where, whereArgs, err := query.whereOrPrimaryKey()
+	if err != nil {
+		return "", nil, err
+	}
+	sql += where
+	args = append(args, whereArgs...)
+
+	if version != nil {
+		sql += " AND " + query.Table + "." + version.Column + "=?"
+		args = append(args, query.Model.FieldByIndex(version.Index).Interface())
+	}
+
+	return
+}
+
+var errNoChanges = errors.New("No changes to save")
+
+//BuildIncrement adds to or subtracts from a column in place, on the rows matching the conditions
+//or the model's primary key, e.g. UPDATE posts SET views=views+? WHERE posts.id=?
+func (query *Query) BuildIncrement(column string, operator string, n interface{}) (sql string, args []interface{}, err error) {
+	if query.schema == nil {
+		return "", nil, errors.New("No model given to update")
+	}
+
+	field := query.fieldByColumn(column)
+	if field == nil {
+		return "", nil, errors.New("Model " + query.schema.Type.Name() + " has no column " + column)
+	}
+
+	if err = query.BuildConditions(); err != nil {
+		return "", nil, err
+	}
+
+	setSQL := []string{field.Column + "=" + field.Column + operator + "?"}
+	args = append(args, n)
+
+	if updated, value, ok := query.touchUpdate(query.Model, query.now()); ok && updated != field {
+		setSQL = append(setSQL, updated.Column+"=?")
+		args = append(args, value)
+	}
+
+	//rows matched by conditions skip soft deleted ones, as UpdateColumns does
+	bulk := query.Where != ""
+
+	where, whereArgs, err := query.whereOrPrimaryKey()
+	if err != nil {
+		return "", nil, err
+	}
+
+	sql = fmt.Sprintf("UPDATE %s SET %s%s", query.Table, strings.Join(setSQL, ","), where)
+	args = append(args, whereArgs...)
+
+	if condition := trashedCondition(query.schema, query.trashed); bulk && condition != "" {
+		sql += " AND " + condition
+	}
+
+	return
+}
+
+//BuildBulkUpdate ...
+func (query *Query) BuildBulkUpdate() (sql string, args []interface{}, err error) {
+	if err = query.checkBulk("update"); err != nil {
+		return "", nil, err
+	}
+
+	if len(query.updates) == 0 {
+		return "", nil, errors.New("No columns to update")
+	}
+
+	setSQL, args, err := query.buildUpdates()
+	if err != nil {
+		return "", nil, err
+	}
+
+	if field, value, ok := query.touchUpdate(reflect.Value{}, query.now()); ok && !query.isUpdating(field) {
+		setSQL = append(setSQL, field.Column+"=?")
+		args = append(args, value)
+	}
+
+	sql = fmt.Sprintf("UPDATE %s SET %s%s", query.Table, strings.Join(setSQL, ","), query.Where)
+	args = append(args, query.Args...)
+
+	return
+}
+
+//BuildBulkDelete ...
+func (query *Query) BuildBulkDelete() (sql string, args []interface{}, err error) {
+	if err = query.checkBulk("delete"); err != nil {
+		return "", nil, err
+	}
+
+	if field := softDelete(query.schema); field != nil && !query.force {
+		sql = fmt.Sprintf("UPDATE %s SET %s=?%s", query.Table, field.Column, query.Where)
+		args = append(args, fieldValue(deletedValue(field, query.now())))
+	} else {
+		sql = "DELETE FROM " + query.Table + query.Where
+	}
+	args = append(args, query.Args...)
+
+	return
+}
+
+//checkBulk builds the conditions of a bulk statement and refuses to run it on every row unless AllowGlobal was called.
+func (query *Query) checkBulk(operation string) error {
+	if query.schema == nil {
+		return errors.New("No model to " + operation + ", call Model() first")
+	}
+
+	if err := query.BuildConditions(); err != nil {
+		return err
+	}
+
+	if query.Where == "" && !query.global {
+		return errors.New("Refusing to " + operation + " every row of " + query.Table + " without conditions, call AllowGlobal() first")
+	}
+	query.scopeTrashed()
+
+	return nil
+}
+
+//setModel sets the model a bulk statement runs on.
+func (query *Query) setModel(Model interface{}) error {
+	modelStruct := reflect.Indirect(reflect.ValueOf(Model))
+	if modelStruct.Kind() != reflect.Struct {
+		return ErrNotAStruct
 	}
 
-	if query.Select != "" {
-		sql = query.Select
-	} else {
-		sql = "SELECT " + query.getColumns()
-	}
+	query.schema = query.getSchema(modelStruct.Type())
+	query.Model = modelStruct
+	query.Table = query.schema.Table
 
-	sql += " FROM " + query.Table
+	return nil
+}
 
-	if query.Join != "" {
-		sql += query.Join
+//isWritable reports whether a field is written by an update restricted with Select or Omit.
+func (query *Query) isWritable(field *modelField) bool {
+	for _, column := range query.omitted {
+		if strings.TrimPrefix(column, query.Table+".") == field.Column || column == field.Name {
+			return false
+		}
 	}
-	if query.Where != "" {
-		sql += query.Where
+
+	if len(query.selected) == 0 {
+		return true
 	}
-	if query.GroupBy != "" {
-		sql += query.GroupBy
+
+	for _, column := range query.selected {
+		if strings.TrimPrefix(column, query.Table+".") == field.Column || column == field.Name {
+			return true
+		}
 	}
-	if query.Having != "" {
-		sql += query.Having
+
+	return false
+}
+
+//buildUpdates renders the SET clause of an Update, in column order for stable statements.
+func (query *Query) buildUpdates() (setSQL []string, args []interface{}, err error) {
+	columns := make([]string, 0, len(query.updates))
+	for column := range query.updates {
+		columns = append(columns, column)
 	}
-	if query.OrderBy != "" {
-		sql += query.OrderBy
+	sort.Strings(columns)
+
+	for _, column := range columns {
+		value := query.updates[column]
+
+		if field := query.fieldByColumn(column); field != nil {
+			column = field.Column
+			if value, err = query.updateValue(field, value); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		setSQL = append(setSQL, column+"=?")
+		args = append(args, value)
 	}
-	if query.Offset != "" {
-		sql += query.Offset
+
+	return setSQL, args, nil
+}
+
+//updateValue encodes a value written to a field by an Update as columnValue encodes the field,
+//passing values of other types, such as raw column values, through as they are.
+func (query *Query) updateValue(field *modelField, value interface{}) (interface{}, error) {
+	if field.JSON {
+		return jsonValue(reflect.ValueOf(value))
 	}
-	if query.Limit != "" {
-		sql += query.Limit
+
+	if converter, ok := query.types().Get(field.Type); ok && value != nil {
+		if v := reflect.ValueOf(value); v.Type() == converter.Type || v.Type() == reflect.PtrTo(converter.Type) {
+			return converter.value(v)
+		}
 	}
 
-	fmt.Println(sql)
+	return value, nil
+}
 
-	return
+//isUpdating reports whether an Update or UpdateColumns sets the field explicitly.
+func (query *Query) isUpdating(field *modelField) bool {
+	for column := range query.updates {
+		if query.fieldByColumn(column) == field {
+			return true
+		}
+	}
+
+	return false
 }
 
-//BuildInsert ...
-func (query *Query) BuildInsert() (sql string, err error) {
-	sql = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", query.Table, query.getColumns(), query.getParams())
+//fieldByColumn finds a field of the model by column or field name.
+func (query *Query) fieldByColumn(column string) *modelField {
+	return query.schema.FieldByColumn(strings.TrimPrefix(column, query.Table+"."))
+}
 
-	fmt.Println(sql)
-	return
+//applyUpdates sets the model fields written by an Update.
+func (query *Query) applyUpdates() error {
+	return assignValues(query.schema, query.Model, query.updates)
 }
 
-//BuildUpdate ...
-func (query *Query) BuildUpdate() (sql string, args []interface{}, err error) {
-	if err = query.BuildConditions(); err != nil {
-		return "", nil, err
+//assignValues sets the model fields named by column or field name to the given values. Values
+//must be assignable to the field, or of the same numeric kind and in range, so that the model
+//holds exactly what is written to its row. nil clears nullable fields.
+func assignValues(modelSchema *schema, model reflect.Value, values map[string]interface{}) error {
+	for column, value := range values {
+		field := modelSchema.FieldByColumn(column)
+		if field == nil {
+			continue
+		}
+
+		structField := model.FieldByIndex(field.Index)
+		if !structField.CanSet() {
+			continue
+		}
+
+		if err := assignValue(structField, value); err != nil {
+			return errors.New("Field " + field.Name + " " + err.Error())
+		}
 	}
 
-	sql = fmt.Sprintf("UPDATE %s SET ", query.Table)
+	return nil
+}
 
-	setSQL := []string{}
-	for i, col := range query.Columns {
-		if col == query.Table+".id" || col == query.Table+".created_at" || col == query.Table+".updated_at" {
-			continue
+//assignValue sets a field to a value written to its column.
+func assignValue(structField reflect.Value, value interface{}) error {
+	fieldType := structField.Type()
+
+	if value == nil {
+		switch fieldType.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+		default:
+			if !reflect.PtrTo(fieldType).Implements(scannerType) {
+				return fmt.Errorf("of type %s cannot be set to NULL", fieldType)
+			}
 		}
-		setSQL = append(setSQL, col+"=?")
-		args = append(args, query.Values[i])
+
+		structField.Set(reflect.Zero(fieldType))
+		return nil
 	}
-	sql += strings.Join(setSQL, ",")
 
-	if query.Where != "" {
-		sql += query.Where
-	} else {
-		sql += " WHERE " + query.Table + ".id=?"
-		args = append(args, query.Model.FieldByName("Id").Interface())
+	v := reflect.ValueOf(value)
+	if v.Type().AssignableTo(fieldType) {
+		structField.Set(v)
+		return nil
 	}
 
-	fmt.Println(sql)
+	if fieldType.Kind() == reflect.Ptr {
+		ptr := reflect.New(fieldType.Elem())
+		if err := assignValue(ptr.Elem(), value); err != nil {
+			return fmt.Errorf("of type %s cannot be set to %#v", fieldType, value)
+		}
+		structField.Set(ptr)
+		return nil
+	}
 
-	return
+	//integers convert across signedness when the value fits, as untyped constants are ints
+	converted := reflect.New(fieldType).Elem()
+	switch {
+	case isSigned(v.Kind()) && isSigned(fieldType.Kind()) && !converted.OverflowInt(v.Int()):
+		converted.SetInt(v.Int())
+	case isSigned(v.Kind()) && isUnsigned(fieldType.Kind()) && v.Int() >= 0 && !converted.OverflowUint(uint64(v.Int())):
+		converted.SetUint(uint64(v.Int()))
+	case isUnsigned(v.Kind()) && isUnsigned(fieldType.Kind()) && !converted.OverflowUint(v.Uint()):
+		converted.SetUint(v.Uint())
+	case isUnsigned(v.Kind()) && isSigned(fieldType.Kind()) && v.Uint() <= math.MaxInt64 && !converted.OverflowInt(int64(v.Uint())):
+		converted.SetInt(int64(v.Uint()))
+	case isFloat(v.Kind()) && isFloat(fieldType.Kind()) && !converted.OverflowFloat(v.Float()):
+		converted.SetFloat(v.Float())
+	default:
+		return fmt.Errorf("of type %s cannot be set to %#v", fieldType, value)
+	}
+
+	structField.Set(converted)
+	return nil
+}
+
+func isSigned(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Int64
+}
+
+func isUnsigned(kind reflect.Kind) bool {
+	return kind >= reflect.Uint && kind <= reflect.Uintptr
+}
+
+func isFloat(kind reflect.Kind) bool {
+	return kind == reflect.Float32 || kind == reflect.Float64
 }
 
 //BuildDelete ...
@@ -389,50 +1448,123 @@ func (query *Query) BuildDelete() (sql string, args []interface{}, err error) {
 		return "", nil, err
 	}
 
-	sql = "DELETE FROM " + query.Table
+	where, args, err := query.whereOrPrimaryKey()
+	if err != nil {
+		return "", nil, err
+	}
+	sql = "DELETE FROM " + query.Table + where
+
+	return
+}
+
+//BuildSoftDelete sets the DeletedAt column of the matching rows, or clears it when restoring,
+//and sets the model's field to match.
+func (query *Query) BuildSoftDelete(restore bool) (sql string, args []interface{}, err error) {
+	field := softDelete(query.schema)
+	if field == nil {
+		return "", nil, errors.New("Model has no " + softDeleteField + " field to soft delete with")
+	}
+
+	if err = query.BuildConditions(); err != nil {
+		return "", nil, err
+	}
+
+	value := deletedValue(field, query.now())
+	scope := query.trashed
+	if restore {
+		value = deletedValue(field, time.Time{})
+		if scope == withoutTrashed {
+			scope = onlyTrashed
+		}
+	}
 
-	if query.Where != "" {
-		sql += query.Where
-	} else {
-		sql += " WHERE " + query.Table + ".id=?"
-		args = append(args, query.Model.FieldByName("Id").Interface())
+	where, whereArgs, err := query.whereOrPrimaryKey()
+	if err != nil {
+		return "", nil, err
+	}
+
+	sql = fmt.Sprintf("UPDATE %s SET %s=?%s", query.Table, field.Column, where)
+	args = append([]interface{}{fieldValue(value)}, whereArgs...)
+
+	if condition := trashedCondition(query.schema, scope); condition != "" {
+		sql += " AND " + condition
 	}
 
-	fmt.Println(sql)
+	if query.Model.CanSet() {
+		query.Model.FieldByIndex(field.Index).Set(value)
+	}
 
 	return
 }
 
+//whereOrPrimaryKey returns the conditions of the query, or the model's primary key when there are none.
+func (query *Query) whereOrPrimaryKey() (string, []interface{}, error) {
+	if query.Where != "" {
+		return query.Where, query.Args, nil
+	}
+
+	return query.primaryKey()
+}
+
 //fillRows ...
 func (query *Query) fillRows(rows *sql.Rows) ([]interface{}, error) {
+	//scan into driver values rather than sql.RawBytes, which can't tell NULL from an empty string
+	columns := make([]interface{}, len(query.Columns))
 	values := make([]sql.RawBytes, len(query.Columns))
 	scanArgs := make([]interface{}, len(query.Columns))
 
-	for i := range values {
-		scanArgs[i] = &values[i]
+	for i := range columns {
+		scanArgs[i] = &columns[i]
 	}
 
 	var rowCount int
 	var results []interface{}
+	var found []reflect.Value
 	var index int
 
 	for rows.Next() {
 		rowCount++
 		err := rows.Scan(scanArgs...)
 		if err != nil {
-			return nil, errors.New(err.Error())
+			return nil, err
 		}
 
-		if err := query.fillModel(query.Model, values, index); err != nil {
+		for i, column := range columns {
+			values[i] = rawValue(column)
+		}
+
+		if _, err := query.fillModel(query.Model, values, index); err != nil {
 			return nil, err
 		}
+		query.takeSnapshot(query.Model)
 
-		// fmt.Printf("%#v \n", query.Model.Interface())
-		results = append(results, query.Model.Interface())
+		model := reflect.New(query.Model.Type()).Elem()
+		model.Set(query.Model)
+		found = append(found, model)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, errors.New(err.Error())
+		return nil, err
+	}
+
+	//AfterFind hooks can query through the transaction, whose connection is busy until the rows are closed
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	for _, model := range found {
+		if query.db != nil {
+			if err := query.db.runHooks(model, afterFind); err != nil {
+				return nil, err
+			}
+		}
+
+		results = append(results, model.Interface())
+	}
+
+	//the model given holds the last row, as changed by its hook
+	if len(found) > 0 && query.Model.CanSet() {
+		query.Model.Set(found[len(found)-1])
 	}
 
 	// if rowCount == 0 {
@@ -442,86 +1574,206 @@ func (query *Query) fillRows(rows *sql.Rows) ([]interface{}, error) {
 	return results, nil
 }
 
-//fillModel ...
-func (query *Query) fillModel(Model reflect.Value, values []sql.RawBytes, index int) (err error) {
+//fillModel fills the model from values starting at index and returns the index of the next unread column.
+func (query *Query) fillModel(Model reflect.Value, values []sql.RawBytes, index int) (next int, err error) {
 	defer func() {
 		if err2 := recover(); err2 != nil {
 			err = errors.New(fmt.Sprintf("Exception: %v", err2))
 		}
 	}()
 
-	for i := 0; i < Model.NumField(); i++ {
-		field := Model.Type().Field(i)
-		fieldName := field.Name
-		structField := Model.FieldByName(fieldName)
+	for _, field := range query.getSchema(Model.Type()).Fields {
+		structField := Model.FieldByIndex(field.Index)
 
-		if !structField.CanSet() {
+		if !field.Relation {
+			if structField.CanSet() {
+				if err := query.fillColumn(field, structField, index, values); err != nil {
+					return index, err
+				}
+			}
+			index++
+			continue
+		}
+
+		joinModel, hasJoin := query.joinFor(field)
+		if !hasJoin {
+			//skip the placeholder column selected by mapColumns
+			index++
 			continue
 		}
 
-		structKind := structField.Type().Kind()
-		if structKind == reflect.Slice || structKind == reflect.Struct || structKind == reflect.Ptr {
-			structType := reflect.TypeOf(structField.Interface())
-			if structKind == reflect.Slice {
-				structType = structType.Elem()
+		if field.JSONObject != "" {
+			if structField.CanSet() {
+				data := reflect.New(field.Type)
+				json.Unmarshal(values[index], data.Interface())
+				structField.Set(data.Elem())
 			}
+			index++
+			continue
+		}
 
-			var modelStruct reflect.Value
+		joined := reflect.New(reflect.TypeOf(joinModel)).Elem()
 
-			if structKind == reflect.Ptr {
-				test := reflect.New(structType.Elem()).Interface()
-				test2 := reflect.ValueOf(test).Elem()
-				if test2.Type().Kind() == reflect.Slice {
-					modelStruct = reflect.New(test2.Type().Elem()).Elem()
-				} else {
-					modelStruct = reflect.New(test2.Type()).Elem()
-				}
+		//a LEFT JOIN without a match selects NULL for every column of the joined model
+		count := query.columnCount(query.getSchema(joined.Type()))
+		if isNullRange(values[index : index+count]) {
+			index += count
+			continue
+		}
+
+		index, err = query.fillModel(joined, values, index)
+		if err != nil {
+			return index, err
+		}
+
+		if structField.CanSet() {
+			if structField.Kind() == reflect.Ptr {
+				structField.Set(joined.Addr())
 			} else {
-				modelStruct = reflect.New(structType).Elem()
+				structField.Set(joined)
 			}
+		}
+	}
 
-			hasJoin := false
-			for _, joinModel := range query.Joins {
-				if joinModel == modelStruct.Interface() {
-					hasJoin = true
-
-					jsonObject := field.Tag.Get("json_object")
-					if jsonObject != "" {
-						data := reflect.New(structType.Elem()).Interface()
-						json.Unmarshal(values[index+i], &data)
-						structField.Set(reflect.ValueOf(data))
-					} else {
-						if err := query.fillModel(modelStruct, values, index+i); err != nil {
-							return err
-						}
-
-						field := reflect.New(reflect.TypeOf(joinModel))
-						field.Elem().Set(reflect.ValueOf(modelStruct.Interface()))
-						structField.Set(field)
-					}
-
-					break
-				}
-			}
+	return index, nil
+}
 
-			if !hasJoin {
-				//fmt.Printf("%T does not have join\n", modelStruct.Interface())
-			}
+//fillColumn fills a column field using its JSON, registered type or default conversion.
+func (query *Query) fillColumn(field *modelField, structField reflect.Value, index int, values []sql.RawBytes) error {
+	if field.JSON {
+		if err := scanJSON(structField, values[index]); err != nil {
+			return fmt.Errorf("Field %s %w", field.Name, err)
+		}
+		return nil
+	}
+
+	if converter, ok := query.types().Get(field.Type); ok {
+		if err := converter.scan(structField, values[index]); err != nil {
+			return fmt.Errorf("Field %s %w", field.Name, err)
+		}
+		return nil
+	}
+
+	return query.fillField(index, structField, field.Name, values)
+}
+
+//returningColumns returns the columns read back after an insert on dialects supporting RETURNING.
+func (query *Query) returningColumns() (columns []string) {
+	for _, field := range query.schema.Fields {
+		if !field.Relation {
+			columns = append(columns, field.Column)
+		}
+	}
+
+	return columns
+}
+
+var errNoRowReturned = errors.New("Insert returned no row")
+
+//fillReturning fills the model with the row returned by INSERT ... RETURNING, picking up
+//generated keys and column defaults.
+func (query *Query) fillReturning(model reflect.Value, rows *sql.Rows) error {
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return errNoRowReturned
+	}
+
+	columns := make([]interface{}, len(query.returningColumns()))
+	scanArgs := make([]interface{}, len(columns))
+	for i := range columns {
+		scanArgs[i] = &columns[i]
+	}
 
+	if err := rows.Scan(scanArgs...); err != nil {
+		return err
+	}
+
+	values := make([]sql.RawBytes, len(columns))
+	for i, column := range columns {
+		values[i] = rawValue(column)
+	}
+
+	index := 0
+	for _, field := range query.schema.Fields {
+		if field.Relation {
 			continue
+		}
 
+		if err := query.fillColumn(field, model.FieldByIndex(field.Index), index, values); err != nil {
+			return err
 		}
+		index++
+	}
 
-		err := query.fillField(index+i, structField, fieldName, values)
-		if err != nil {
-			return errors.New(err.Error())
+	return rows.Err()
+}
+
+//fillInsertIds sets the integer primary keys of models inserted by a single statement, when
+//every key was generated by the database.
+func (query *Query) fillInsertIds(models []reflect.Value, res sql.Result) error {
+	if len(query.schema.PrimaryKeys) != 1 {
+		return nil
+	}
+
+	index := query.schema.PrimaryKeys[0].Index
+	for _, model := range models {
+		switch key := model.FieldByIndex(index); key.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if !key.IsZero() {
+				return nil
+			}
+		default:
+			return nil
+		}
+	}
+
+	ids, err := query.dialect().InsertIds(res, len(models))
+	if err != nil || ids == nil {
+		return err
+	}
+
+	for i, model := range models {
+		key := model.FieldByIndex(index)
+		if key.Kind() >= reflect.Uint && key.Kind() <= reflect.Uint64 {
+			key.SetUint(uint64(ids[i]))
+		} else {
+			key.SetInt(ids[i])
 		}
 	}
 
 	return nil
 }
 
-//fillField ...9
+//columnCount returns the number of columns mapColumns selects for a model.
+func (query *Query) columnCount(modelSchema *schema) (count int) {
+	for _, field := range modelSchema.Fields {
+		if field.Relation && field.JSONObject == "" {
+			if joinModel, ok := query.joinFor(field); ok {
+				count += query.columnCount(query.getSchema(reflect.Indirect(reflect.ValueOf(joinModel)).Type()))
+				continue
+			}
+		}
+		count++
+	}
+
+	return count
+}
+
+//isNullRange reports whether every value is NULL.
+func isNullRange(values []sql.RawBytes) bool {
+	for _, value := range values {
+		if value != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+//fillField ...
 func (query *Query) fillField(index int, structField reflect.Value, fieldName string, values []sql.RawBytes) (err error) {
 	defer func() {
 		if err2 := recover(); err2 != nil {
@@ -529,44 +1781,56 @@ func (query *Query) fillField(index int, structField reflect.Value, fieldName st
 		}
 	}()
 
-	var v interface{}
+	if err := scanValue(structField, values[index], query.dialect()); err != nil {
+		return fmt.Errorf("Field %s %w", fieldName, err)
+	}
 
-	//fmt.Printf("%v: %#v – %T\n", index, index, index)
+	return nil
+}
 
-	val := values[index]
+//columnValue returns the value written to a field's column.
+func (query *Query) columnValue(field *modelField, structField reflect.Value) (interface{}, error) {
+	if field.JSON {
+		return jsonValue(structField)
+	}
 
-	switch structField.Type().Kind() {
-	case reflect.Slice:
-		v = val
-	case reflect.String:
-		v = string(val)
-	case reflect.Bool:
-		v = string(val) == "1"
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		v, err = strconv.Atoi(string(val))
-		if err != nil {
-			return errors.New("Field " + fieldName + " as int: " + err.Error())
-		}
-	case reflect.Float32, reflect.Float64:
-		v, err = strconv.ParseFloat(string(val), 64)
-		if err != nil {
-			return errors.New("Field " + fieldName + " as float64: " + err.Error())
-		}
-	default:
-		return errors.New("Unsupported type in Scan: " + reflect.TypeOf(v).String())
+	if converter, ok := query.types().Get(field.Type); ok {
+		return converter.value(structField)
 	}
 
-	// fmt.Printf("%v: %#v – %T\n", fieldName, v, v)
-	// fmt.Println("--------------")
+	return fieldValue(structField), nil
+}
+
+//getSchema returns the schema of a model type as seen by the query's connection.
+func (query *Query) getSchema(modelType reflect.Type) *schema {
+	return getSchema(modelType, query.types(), query.naming())
+}
 
-	structField.Set(reflect.ValueOf(v))
+//naming returns the naming strategy of the query's connection.
+func (query *Query) naming() NamingStrategy {
+	if query.db == nil || query.db.Naming == nil {
+		return Naming{}
+	}
 
-	return nil
+	return query.db.Naming
+}
+
+//types returns the custom types registered on the query's connection.
+func (query *Query) types() *typeRegistry {
+	if query.db == nil {
+		return nil
+	}
+
+	return query.db.types
 }
 
-//getTable ...
-func (query *Query) getTable(Model reflect.Value) string {
-	return pluralizeString(snakeCase(Model.Type().Name()))
+//dialect returns the Dialect of the connection the query runs on, defaulting to MySQL.
+func (query *Query) dialect() Dialect {
+	if query.db == nil {
+		return mysqlDialect{}
+	}
+
+	return query.db.dialect()
 }
 
 //getParams ... TODO: possibly remove/refactor - not needed for simple method
@@ -583,75 +1847,71 @@ func (query *Query) getColumns() string {
 func (query *Query) mapStruct(Model interface{}) error {
 	modelStruct := reflect.Indirect(reflect.ValueOf(Model))
 	if modelStruct.Kind() != reflect.Struct {
-		return errors.New("Model given is not a struct")
+		return ErrNotAStruct
 	}
 
-	tableName := query.getTable(modelStruct)
+	modelSchema := query.getSchema(modelStruct.Type())
 
 	if !query.Model.IsValid() {
 		query.Model = modelStruct
-		query.Table = tableName
+		query.Table = modelSchema.Table
+		query.schema = modelSchema
 	}
 
-	var v interface{}
-
-	for i := 0; i < modelStruct.NumField(); i++ {
-		field := modelStruct.Type().Field(i)
-		fieldName := field.Name
-		structField := modelStruct.FieldByName(fieldName)
-		structFieldKind := modelStruct.Field(i).Kind()
-
-		if structFieldKind == reflect.Slice || structFieldKind == reflect.Struct || structFieldKind == reflect.Ptr {
-			structType := reflect.TypeOf(structField.Interface())
-			if structFieldKind == reflect.Slice {
-				structType = structType.Elem()
-			}
-
-			var modelStruct reflect.Value
-
-			if structFieldKind == reflect.Ptr {
-				test := reflect.New(structType.Elem()).Interface()
-				test2 := reflect.ValueOf(test).Elem()
-				if test2.Type().Kind() == reflect.Slice {
-					modelStruct = reflect.New(test2.Type().Elem()).Elem()
-				} else {
-					modelStruct = reflect.New(test2.Type()).Elem()
-				}
-			} else {
-				modelStruct = reflect.New(structType).Elem()
-			}
+	return query.mapColumns(modelSchema, modelStruct)
+}
 
-			hasJoin := false
-			for _, joinModel := range query.Joins {
-				if joinModel == modelStruct.Interface() {
-					hasJoin = true
-					query.BuildJoin(joinModel)
-					break
+//mapColumns adds the columns of a model, and of any models joined through its relations, to the query.
+func (query *Query) mapColumns(modelSchema *schema, modelStruct reflect.Value) error {
+	for _, field := range modelSchema.Fields {
+		if field.Relation {
+			if joinModel, ok := query.joinFor(field); ok {
+				if err := query.buildJoin(modelSchema, field, joinModel); err != nil {
+					return err
 				}
+				continue
 			}
 
-			if !hasJoin {
-				jsonKey := field.Tag.Get("json")
-				query.Columns = append(query.Columns, "\"\" as "+snakeCase(jsonKey))
-				query.Values = append(query.Values, nil)
-			}
-
+			query.Columns = append(query.Columns, "'' as "+snakeCase(field.JSONKey))
+			query.Values = append(query.Values, nil)
 			continue
 		}
 
-		key := modelStruct.Type().Field(i).Name
-		val := modelStruct.Field(i).Interface()
-
-		if val == "" {
-			v = nil
-		} else {
-			v = val
+		value, err := query.columnValue(field, modelStruct.FieldByIndex(field.Index))
+		if err != nil {
+			return err
 		}
 
-		query.Columns = append(query.Columns, tableName+"."+snakeCase(key))
+		query.Columns = append(query.Columns, modelSchema.Table+"."+field.Column)
 		query.Params = append(query.Params, "?")
-		query.Values = append(query.Values, v)
+		query.Values = append(query.Values, value)
 	}
 
 	return nil
 }
+
+//joinFor returns the joined model matching a relation field, if any.
+func (query *Query) joinFor(field *modelField) (interface{}, bool) {
+	for _, joinModel := range query.Joins {
+		if reflect.Indirect(reflect.ValueOf(joinModel)).Type() == field.RelationType {
+			return joinModel, true
+		}
+	}
+
+	return nil, false
+}
+
+//primaryKey returns the condition matching the model's primary key columns along with their values.
+func (query *Query) primaryKey() (where string, args []interface{}, err error) {
+	if query.schema == nil || len(query.schema.PrimaryKeys) == 0 {
+		return "", nil, errors.New("Model has no primary key")
+	}
+
+	var conditions []string
+	for _, field := range query.schema.PrimaryKeys {
+		conditions = append(conditions, query.Table+"."+field.Column+"=?")
+		args = append(args, query.Model.FieldByIndex(field.Index).Interface())
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args, nil
+}
@@ -1,6 +1,139 @@
 package cworm
 
+import (
+	"errors"
+	"reflect"
+	"sort"
+)
+
 //New ...
 func (db *DB) New(Model interface{}) (interface{}, error) {
 	return db.Insert(Model)
 }
+
+//FirstOrInit loads the first model matching the attributes, or sets the attributes on the model
+//when there is none. Attributes are keyed by column or field name.
+func (db *DB) FirstOrInit(Model interface{}, attrs map[string]interface{}) error {
+	if db.HasErrors() {
+		return db.ReturnError(db.ErrorMessages())
+	}
+
+	conditions := db.Query.Conditions
+	db.ResetQuery()
+
+	found, err := db.firstBy(Model, conditions, attrs)
+	if err != nil || found {
+		return err
+	}
+
+	return db.assign(Model, attrs)
+}
+
+//FirstOrCreate loads the first model matching the attributes, or inserts the model with the
+//attributes set when there is none.
+func (db *DB) FirstOrCreate(Model interface{}, attrs map[string]interface{}) error {
+	if db.HasErrors() {
+		return db.ReturnError(db.ErrorMessages())
+	}
+
+	conditions := db.Query.Conditions
+	db.ResetQuery()
+
+	return db.createTransaction(func(tx *DB) error {
+		found, err := tx.firstBy(Model, conditions, attrs)
+		if err != nil || found {
+			return err
+		}
+
+		if err := tx.assign(Model, attrs); err != nil {
+			return err
+		}
+
+		_, err = tx.Insert(Model)
+		return err
+	})
+}
+
+//UpdateOrCreate updates the first model matching the attributes with the values, or inserts the
+//model with both the attributes and the values set when there is none.
+func (db *DB) UpdateOrCreate(Model interface{}, attrs map[string]interface{}, values map[string]interface{}) error {
+	if db.HasErrors() {
+		return db.ReturnError(db.ErrorMessages())
+	}
+
+	conditions := db.Query.Conditions
+	db.ResetQuery()
+
+	return db.createTransaction(func(tx *DB) error {
+		found, err := tx.firstBy(Model, conditions, attrs)
+		if err != nil {
+			return err
+		}
+
+		if found {
+			return tx.Update(Model, values)
+		}
+
+		if err := tx.assign(Model, attrs); err != nil {
+			return err
+		}
+		if err := tx.assign(Model, values); err != nil {
+			return err
+		}
+
+		_, err = tx.Insert(Model)
+		return err
+	})
+}
+
+//createTransaction runs fn in a transaction, and runs it once more when its insert lost a race
+//with another writer creating the same row, so the second run finds it. Calls nested in an open
+//transaction aren't retried, as some databases abort the whole transaction on the failed insert.
+func (db *DB) createTransaction(fn func(tx *DB) error) error {
+	err := db.Transaction(fn)
+	if err != nil && db.Tx == nil && errors.Is(err, ErrUniqueViolation) {
+		err = db.Transaction(fn)
+	}
+
+	return err
+}
+
+//firstBy loads the first model matching the conditions and attributes, reporting whether there was one.
+func (db *DB) firstBy(Model interface{}, conditions []interface{}, attrs map[string]interface{}) (bool, error) {
+	modelStruct := reflect.Indirect(reflect.ValueOf(Model))
+	if modelStruct.Kind() != reflect.Struct {
+		return false, db.ReturnError(ErrNotAStruct)
+	}
+
+	modelSchema := db.Query.getSchema(modelStruct.Type())
+
+	columns := make([]string, 0, len(attrs))
+	for column := range attrs {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	db.Query.Conditions = append(db.Query.Conditions, conditions...)
+	for _, column := range columns {
+		where := column
+		if field := modelSchema.FieldByColumn(column); field != nil {
+			where = field.Column
+		}
+
+		db.Where(where, "=", attrs[column])
+	}
+
+	rows, err := db.Limit(1).Get(Model)
+
+	return len(rows) > 0, err
+}
+
+//assign sets the model fields named by the attributes.
+func (db *DB) assign(Model interface{}, attrs map[string]interface{}) error {
+	modelStruct := reflect.Indirect(reflect.ValueOf(Model))
+	if modelStruct.Kind() != reflect.Struct || !modelStruct.CanSet() {
+		return errors.New("Model given is not a pointer to a struct")
+	}
+
+	return assignValues(db.Query.getSchema(modelStruct.Type()), modelStruct, attrs)
+}
@@ -0,0 +1,330 @@
+package cworm
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type dialectPost struct {
+	Id    int
+	Title string
+	Views int
+}
+
+type counterPost struct {
+	Model
+	Views uint
+}
+
+type requiredPost struct {
+	Id    int
+	Title string `cworm:"required"`
+}
+
+//statementTest is a builder chain expected to render a single statement, keyed by dialect name.
+type statementTest struct {
+	name string
+	fn   func(db *DB) error
+	sql  map[string]string
+	args []interface{}
+}
+
+//testStatements renders each chain with ToSQL on every dialect.
+func testStatements(t *testing.T, tests []statementTest) {
+	for _, dialect := range []Dialect{mysqlDialect{}, postgresDialect{}, sqliteDialect{}} {
+		for _, test := range tests {
+			t.Run(dialect.Name()+"/"+test.name, func(t *testing.T) {
+				db := &DB{Dialect: dialect}
+
+				statements, err := db.ToSQL(test.fn)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(statements) != 1 {
+					t.Fatalf("got %d statements, want 1", len(statements))
+				}
+
+				if got, want := statements[0].SQL, test.sql[dialect.Name()]; got != want {
+					t.Errorf("got SQL %q, want %q", got, want)
+				}
+				if got := statements[0].Args; !reflect.DeepEqual(got, test.args) {
+					t.Errorf("got args %#v, want %#v", got, test.args)
+				}
+			})
+		}
+	}
+}
+
+func TestToSQL(t *testing.T) {
+	testStatements(t, []statementTest{
+		{
+			name: "select",
+			fn: func(db *DB) error {
+				_, err := db.Where("title", "=", "Hello").Limit(2).Get(&dialectPost{})
+				return err
+			},
+			sql: map[string]string{
+				"mysql":    "SELECT dialect_posts.id,dialect_posts.title,dialect_posts.views FROM dialect_posts WHERE dialect_posts.title = ? LIMIT 2",
+				"postgres": "SELECT dialect_posts.id,dialect_posts.title,dialect_posts.views FROM dialect_posts WHERE dialect_posts.title = $1 LIMIT 2",
+				"sqlite3":  "SELECT dialect_posts.id,dialect_posts.title,dialect_posts.views FROM dialect_posts WHERE dialect_posts.title = ? LIMIT 2",
+			},
+			args: []interface{}{"Hello"},
+		},
+		{
+			name: "insert",
+			fn: func(db *DB) error {
+				_, err := db.Insert(&dialectPost{Title: "Hello", Views: 1})
+				return err
+			},
+			sql: map[string]string{
+				"mysql":    "INSERT INTO dialect_posts (title,views) VALUES (?,?)",
+				"postgres": "INSERT INTO dialect_posts (title,views) VALUES ($1,$2) RETURNING id,title,views",
+				"sqlite3":  "INSERT INTO dialect_posts (title,views) VALUES (?,?)",
+			},
+			args: []interface{}{"Hello", 1},
+		},
+		{
+			name: "save",
+			fn: func(db *DB) error {
+				return db.Save(&dialectPost{Id: 3, Title: "Hello"})
+			},
+			sql: map[string]string{
+				"mysql":    "UPDATE dialect_posts SET title=?,views=? WHERE dialect_posts.id=?",
+				"postgres": "UPDATE dialect_posts SET title=$1,views=$2 WHERE dialect_posts.id=$3",
+				"sqlite3":  "UPDATE dialect_posts SET title=?,views=? WHERE dialect_posts.id=?",
+			},
+			args: []interface{}{"Hello", 0, 3},
+		},
+		{
+			name: "delete",
+			fn: func(db *DB) error {
+				_, err := db.Delete(&dialectPost{Id: 3})
+				return err
+			},
+			sql: map[string]string{
+				"mysql":    "DELETE FROM dialect_posts WHERE dialect_posts.id=?",
+				"postgres": "DELETE FROM dialect_posts WHERE dialect_posts.id=$1",
+				"sqlite3":  "DELETE FROM dialect_posts WHERE dialect_posts.id=?",
+			},
+			args: []interface{}{3},
+		},
+	})
+}
+
+func TestUpsert(t *testing.T) {
+	testStatements(t, []statementTest{
+		{
+			name: "upsert",
+			fn: func(db *DB) error {
+				_, err := db.Upsert(&dialectPost{Title: "Hello"}, []string{"title"}, []string{"views"})
+				return err
+			},
+			sql: map[string]string{
+				"mysql":    "INSERT INTO dialect_posts (title,views) VALUES (?,?) ON DUPLICATE KEY UPDATE views=VALUES(views)",
+				"postgres": "INSERT INTO dialect_posts (title,views) VALUES ($1,$2) ON CONFLICT (title) DO UPDATE SET views=EXCLUDED.views RETURNING id,title,views",
+				"sqlite3":  "INSERT INTO dialect_posts (title,views) VALUES (?,?) ON CONFLICT (title) DO UPDATE SET views=EXCLUDED.views",
+			},
+			args: []interface{}{"Hello", 0},
+		},
+		{
+			name: "insert ignore",
+			fn: func(db *DB) error {
+				_, err := db.InsertIgnore(&dialectPost{Title: "Hello"}, "title")
+				return err
+			},
+			sql: map[string]string{
+				"mysql":    "INSERT IGNORE INTO dialect_posts (title,views) VALUES (?,?)",
+				"postgres": "INSERT INTO dialect_posts (title,views) VALUES ($1,$2) ON CONFLICT (title) DO NOTHING RETURNING id,title,views",
+				"sqlite3":  "INSERT INTO dialect_posts (title,views) VALUES (?,?) ON CONFLICT (title) DO NOTHING",
+			},
+			args: []interface{}{"Hello", 0},
+		},
+	})
+}
+
+func TestIncrement(t *testing.T) {
+	testStatements(t, []statementTest{
+		{
+			name: "increment by conditions",
+			fn: func(db *DB) error {
+				_, err := db.Model(dialectPost{}).Where("views", ">", 1).Increment(nil, "views", 2)
+				return err
+			},
+			sql: map[string]string{
+				"mysql":    "UPDATE dialect_posts SET views=views+? WHERE dialect_posts.views > ?",
+				"postgres": "UPDATE dialect_posts SET views=views+$1 WHERE dialect_posts.views > $2",
+				"sqlite3":  "UPDATE dialect_posts SET views=views+? WHERE dialect_posts.views > ?",
+			},
+			args: []interface{}{2, 1},
+		},
+	})
+}
+
+func TestStructLiteralSettings(t *testing.T) {
+	db := &DB{Dialect: postgresDialect{}, Naming: Naming{TablePrefix: "cw_"}}
+
+	statements, err := db.ToSQL(func(db *DB) error {
+		_, err := db.Insert(&dialectPost{Title: "Hello"})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "INSERT INTO cw_dialect_posts (title,views) VALUES ($1,$2) RETURNING id,title,views"
+	if len(statements) != 1 || statements[0].SQL != want {
+		t.Errorf("got %#v, want the first statement to be %q", statements, want)
+	}
+}
+
+func TestIncrementRefreshWithConditions(t *testing.T) {
+	db := &DB{}
+
+	statements, err := db.ToSQL(func(db *DB) error {
+		_, err := db.Model(dialectPost{}).Where("views", ">", 1).Refresh().Increment(nil, "views", 1)
+		return err
+	})
+	if err == nil {
+		t.Fatal("got no error, want Refresh rejected with conditions")
+	}
+	if len(statements) != 0 {
+		t.Errorf("got %d statements, want none run before the error", len(statements))
+	}
+}
+
+func TestInsertManyValidatesEveryBatch(t *testing.T) {
+	db := &DB{Dialect: sqliteDialect{}}
+
+	//one placeholder a row puts the last model in a second batch
+	posts := make([]requiredPost, sqliteDialect{}.MaxPlaceholders()+1)
+	for i := range posts[:len(posts)-1] {
+		posts[i].Title = "Hello"
+	}
+
+	statements, err := db.ToSQL(func(db *DB) error {
+		_, err := db.InsertMany(posts)
+		return err
+	})
+
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("got error %v, want ValidationErrors", err)
+	}
+	if len(statements) != 0 {
+		t.Errorf("got %d statements, want none run before the error", len(statements))
+	}
+}
+
+func TestErrorsScopedToChain(t *testing.T) {
+	db := &DB{}
+
+	statements, err := db.ToSQL(func(db *DB) error {
+		if _, err := db.Model(nil).DeleteWhere(); err == nil {
+			t.Error("got no error, want the Model error returned by its chain")
+		}
+
+		_, err := db.Get(&dialectPost{})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("got error %v, want the next chain to run", err)
+	}
+	if len(statements) != 1 {
+		t.Errorf("got %d statements, want 1", len(statements))
+	}
+	if len(db.Errors) != 0 {
+		t.Errorf("got %d deprecated Errors, want them left empty", len(db.Errors))
+	}
+}
+
+func TestToSQLKeepsStatements(t *testing.T) {
+	db := &DB{DryRun: true}
+
+	if _, err := db.Get(&dialectPost{}); err != nil {
+		t.Fatal(err)
+	}
+
+	statements, err := db.ToSQL(func(db *DB) error {
+		_, err := db.Delete(&dialectPost{Id: 1})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(statements) != 1 || len(db.Statements) != 1 {
+		t.Fatalf("got %d statements and %d recorded, want 1 and 1", len(statements), len(db.Statements))
+	}
+	if !db.DryRun {
+		t.Error("got DryRun false, want it restored to true")
+	}
+}
+
+func TestAssignValue(t *testing.T) {
+	five, views := 5, uint(5)
+
+	tests := []struct {
+		name  string
+		field interface{}
+		value interface{}
+		want  interface{}
+		err   string
+	}{
+		{name: "int into uint", field: uint(0), value: 5, want: uint(5)},
+		{name: "int into uint8", field: uint8(0), value: int64(255), want: uint8(255)},
+		{name: "uint into int", field: 0, value: uint(5), want: 5},
+		{name: "int into int8", field: int8(0), value: 5, want: int8(5)},
+		{name: "int into *uint", field: (*uint)(nil), value: 5, want: &views},
+		{name: "int into *int", field: (*int)(nil), value: 5, want: &five},
+		{name: "float32 into float64", field: 0.0, value: float32(1.5), want: 1.5},
+		{name: "nil into pointer", field: &five, value: nil, want: (*int)(nil)},
+		{name: "negative int into uint", field: uint(0), value: -1, err: "cannot be set to -1"},
+		{name: "int into uint8 overflow", field: uint8(0), value: 256, err: "cannot be set to 256"},
+		{name: "uint64 into int64 overflow", field: int64(0), value: uint64(math.MaxUint64), err: "cannot be set to"},
+		{name: "negative int into *uint", field: (*uint)(nil), value: -1, err: "of type *uint cannot be set to -1"},
+		{name: "int into float", field: 0.0, value: 1, err: "cannot be set to 1"},
+		{name: "string into int", field: 0, value: "5", err: "cannot be set to"},
+		{name: "nil into int", field: 0, value: nil, err: "cannot be set to NULL"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			field := reflect.New(reflect.TypeOf(test.field)).Elem()
+			field.Set(reflect.ValueOf(test.field))
+
+			err := assignValue(field, test.value)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("got error %v, want %q", err, test.err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := field.Interface(); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestUpdateUnsignedField(t *testing.T) {
+	db := &DB{}
+	post := counterPost{Model: Model{ID: 1}}
+
+	statements, err := db.ToSQL(func(db *DB) error {
+		return db.Update(&post, map[string]interface{}{"views": 5})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(statements) != 1 || statements[0].Args[0] != 5 {
+		t.Errorf("got %#v, want views set to 5", statements)
+	}
+}
@@ -0,0 +1,108 @@
+package cworm
+
+import (
+	"reflect"
+)
+
+//TypeEncoder converts a field value into the value written to its column.
+type TypeEncoder func(value interface{}) (interface{}, error)
+
+//TypeDecoder converts a raw column value, nil for NULL, into a value of the registered type.
+type TypeDecoder func(value []byte) (interface{}, error)
+
+//typeConverter ...
+type typeConverter struct {
+	Type   reflect.Type
+	Encode TypeEncoder
+	Decode TypeDecoder
+}
+
+//typeRegistry holds the converters registered on a DB. It is never modified once created
+//so schemas parsed with it can be cached.
+type typeRegistry struct {
+	converters map[reflect.Type]*typeConverter
+}
+
+//RegisterType converts fields of the given type, and pointers to it, with encode on writes and decode on reads.
+//
+//	db.RegisterType(reflect.TypeOf(decimal.Decimal{}),
+//		func(v interface{}) (interface{}, error) { return v.(decimal.Decimal).String(), nil },
+//		func(b []byte) (interface{}, error) { return decimal.NewFromString(string(b)) })
+func (db *DB) RegisterType(fieldType reflect.Type, encode TypeEncoder, decode TypeDecoder) {
+	converters := make(map[reflect.Type]*typeConverter)
+	if db.types != nil {
+		for t, converter := range db.types.converters {
+			converters[t] = converter
+		}
+	}
+
+	converters[fieldType] = &typeConverter{Type: fieldType, Encode: encode, Decode: decode}
+	db.types = &typeRegistry{converters: converters}
+}
+
+//Has reports whether a type, or the type it points to, is registered.
+func (types *typeRegistry) Has(fieldType reflect.Type) bool {
+	_, ok := types.Get(fieldType)
+	return ok
+}
+
+//Get returns the converter for a type, or the type it points to.
+func (types *typeRegistry) Get(fieldType reflect.Type) (*typeConverter, bool) {
+	if types == nil {
+		return nil, false
+	}
+
+	if converter, ok := types.converters[fieldType]; ok {
+		return converter, true
+	}
+
+	if fieldType.Kind() == reflect.Ptr {
+		converter, ok := types.converters[fieldType.Elem()]
+		return converter, ok
+	}
+
+	return nil, false
+}
+
+//value encodes a field, writing nil pointers as NULL.
+func (converter *typeConverter) value(structField reflect.Value) (interface{}, error) {
+	if structField.Kind() == reflect.Ptr && structField.Type() != converter.Type {
+		if structField.IsNil() {
+			return nil, nil
+		}
+		structField = structField.Elem()
+	}
+
+	return converter.Encode(structField.Interface())
+}
+
+//scan decodes a raw column value into a field, leaving pointers nil for NULL.
+func (converter *typeConverter) scan(structField reflect.Value, val []byte) error {
+	isPtr := structField.Kind() == reflect.Ptr && structField.Type() != converter.Type
+	if isPtr && val == nil {
+		structField.Set(reflect.Zero(structField.Type()))
+		return nil
+	}
+
+	decoded, err := converter.Decode(val)
+	if err != nil {
+		return err
+	}
+
+	value := reflect.ValueOf(decoded)
+	if !value.IsValid() {
+		structField.Set(reflect.Zero(structField.Type()))
+		return nil
+	}
+
+	value = value.Convert(converter.Type)
+	if isPtr {
+		ptr := reflect.New(converter.Type)
+		ptr.Elem().Set(value)
+		value = ptr
+	}
+
+	structField.Set(value)
+
+	return nil
+}
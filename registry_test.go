@@ -0,0 +1,78 @@
+package cworm
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+//cents is stored as a decimal string, e.g. 150 => "1.50".
+type cents int64
+
+type pricedPost struct {
+	Id       int
+	Price    cents
+	Discount *cents
+}
+
+func centsDB() *DB {
+	db := &DB{}
+	db.RegisterType(reflect.TypeOf(cents(0)),
+		func(v interface{}) (interface{}, error) {
+			return fmt.Sprintf("%d.%02d", v.(cents)/100, v.(cents)%100), nil
+		},
+		func(b []byte) (interface{}, error) {
+			f, err := strconv.ParseFloat(string(b), 64)
+			return cents(f * 100), err
+		})
+
+	return db
+}
+
+func TestUpdateRegisteredType(t *testing.T) {
+	discount := cents(25)
+
+	tests := []struct {
+		name string
+		fn   func(db *DB) error
+		args []interface{}
+	}{
+		{
+			name: "Update",
+			fn: func(db *DB) error {
+				return db.Update(&pricedPost{Id: 1}, map[string]interface{}{"price": cents(150), "discount": &discount})
+			},
+			args: []interface{}{"0.25", "1.50", 1},
+		},
+		{
+			name: "UpdateColumns",
+			fn: func(db *DB) error {
+				_, err := db.Model(pricedPost{}).Where("id", "=", 1).UpdateColumns(map[string]interface{}{"price": cents(150), "discount": nil})
+				return err
+			},
+			args: []interface{}{nil, "1.50", 1},
+		},
+		{
+			name: "raw value",
+			fn: func(db *DB) error {
+				_, err := db.Model(pricedPost{}).Where("id", "=", 1).UpdateColumns(map[string]interface{}{"price": "2.00"})
+				return err
+			},
+			args: []interface{}{"2.00", 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			statements, err := centsDB().ToSQL(test.fn)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(statements) != 1 || !reflect.DeepEqual(statements[0].Args, test.args) {
+				t.Errorf("got %#v, want args %#v", statements, test.args)
+			}
+		})
+	}
+}
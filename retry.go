@@ -0,0 +1,54 @@
+package cworm
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+)
+
+//RetryPolicy configures how a transaction failing with ErrDeadlock or ErrSerialization is re-run.
+//Each retry waits twice as long as the previous one, up to MaxDelay, with jitter so that the
+//transactions which deadlocked each other don't retry in lockstep.
+//
+//	db.Retry = cworm.RetryPolicy{
+//		MaxAttempts: 5,
+//		BaseDelay:   20 * time.Millisecond,
+//		OnRetry: func(attempt int, err error, delay time.Duration) {
+//			log.Printf("retrying transaction after attempt %d: %v", attempt, err)
+//		},
+//	}
+type RetryPolicy struct {
+	//MaxAttempts is the number of times the transaction runs at most, retries are off below 2.
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+
+	//OnRetry is called before waiting to retry, with the failed attempt and its error.
+	OnRetry func(attempt int, err error, delay time.Duration)
+}
+
+//backoff returns the delay before retrying after the given attempt, between half and all
+//of BaseDelay doubled for each previous attempt.
+func (policy RetryPolicy) backoff(attempt int) time.Duration {
+	delay, maxDelay := policy.BaseDelay, policy.MaxDelay
+	if delay <= 0 {
+		delay = 10 * time.Millisecond
+	}
+	if maxDelay <= 0 {
+		maxDelay = time.Second
+	}
+
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+//isRetryable reports whether an error is resolved by running the transaction again.
+func isRetryable(err error) bool {
+	return errors.Is(err, ErrDeadlock) || errors.Is(err, ErrSerialization)
+}
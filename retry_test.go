@@ -0,0 +1,81 @@
+package cworm
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		policy  RetryPolicy
+		attempt int
+		max     time.Duration
+	}{
+		{policy: RetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}, attempt: 1, max: 10 * time.Millisecond},
+		{policy: RetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}, attempt: 2, max: 20 * time.Millisecond},
+		{policy: RetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}, attempt: 3, max: 40 * time.Millisecond},
+		{policy: RetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}, attempt: 4, max: 50 * time.Millisecond},
+		{policy: RetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}, attempt: 40, max: 50 * time.Millisecond},
+		{policy: RetryPolicy{}, attempt: 1, max: 10 * time.Millisecond},
+		{policy: RetryPolicy{}, attempt: 20, max: time.Second},
+	}
+
+	for _, test := range tests {
+		for i := 0; i < 100; i++ {
+			if delay := test.policy.backoff(test.attempt); delay < test.max/2 || delay > test.max {
+				t.Fatalf("backoff(%d) = %s, want between %s and %s", test.attempt, delay, test.max/2, test.max)
+			}
+		}
+	}
+}
+
+func TestTransactionRetry(t *testing.T) {
+	deadlock := &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+
+	tests := []struct {
+		name     string
+		policy   RetryPolicy
+		err      error
+		attempts int
+	}{
+		{name: "retries deadlocks up to MaxAttempts", policy: RetryPolicy{MaxAttempts: 3, BaseDelay: time.Microsecond}, err: deadlock, attempts: 3},
+		{name: "off below 2 attempts", policy: RetryPolicy{MaxAttempts: 1}, err: deadlock, attempts: 1},
+		{name: "other errors run once", policy: RetryPolicy{MaxAttempts: 3, BaseDelay: time.Microsecond}, err: errors.New("syntax error"), attempts: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sqlDB, err := sql.Open("cworm-busy", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer sqlDB.Close()
+
+			db := &DB{DB: sqlDB}
+
+			attempts, retries := 0, 0
+			test.policy.OnRetry = func(attempt int, err error, delay time.Duration) {
+				retries++
+				if attempt != retries || !errors.Is(err, ErrDeadlock) {
+					t.Errorf("got OnRetry(%d, %v), want attempt %d of a deadlock", attempt, err, retries)
+				}
+			}
+
+			err = db.Transaction(func(tx *DB) error {
+				attempts++
+				return test.err
+			}, test.policy)
+
+			if !errors.Is(err, test.err) {
+				t.Errorf("got error %v, want %v", err, test.err)
+			}
+			if attempts != test.attempts || retries != test.attempts-1 {
+				t.Errorf("got %d attempts and %d retries, want %d and %d", attempts, retries, test.attempts, test.attempts-1)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package cworm
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+//schema is the mapping of a model type to its table, columns and relations.
+//It is computed once per type and cached.
+type schema struct {
+	Type        reflect.Type
+	Table       string
+	Fields      []*modelField
+	PrimaryKeys []*modelField
+
+	types  *typeRegistry
+	naming NamingStrategy
+}
+
+//modelField describes how a struct field maps to a column, as configured by its `cworm` tag.
+//
+//	Id    int    `cworm:"primaryKey;autoIncrement"`
+//	Title string `cworm:"column:post_title"`
+//	Slug  string `cworm:"readonly"`
+//	Lock  int    `cworm:"version"`
+//	Meta  Meta   `cworm:"json"`
+//	Audit Audit  `cworm:"embedded;prefix:audit_"`
+//	Temp  string `cworm:"-"`
+//	Email string `cworm:"required;max:191;email"`
+type modelField struct {
+	Name          string
+	Index         []int
+	Type          reflect.Type
+	Column        string
+	Ignore        bool
+	PrimaryKey    bool
+	AutoIncrement bool
+	Readonly      bool
+	Version       bool
+	JSON          bool
+	Embedded      bool
+	Prefix        string
+
+	//Validation rules checked by Insert and Save, see ValidationErrors.
+	Required bool
+	Email    bool
+	Min      *float64
+	Max      *float64
+
+	//Relation fields hold other models and are filled through joins.
+	Relation     bool
+	RelationType reflect.Type
+	JSONKey      string
+	JSONObject   string
+	ForeignKey   string
+}
+
+var schemas sync.Map
+
+//schemaKey caches schemas per registry and naming strategy, as registered types change
+//which fields are columns and the naming strategy changes table and column names.
+type schemaKey struct {
+	Type   reflect.Type
+	Types  *typeRegistry
+	Naming NamingStrategy
+}
+
+//getSchema returns the cached schema for a model type, parsing it on first use.
+func getSchema(modelType reflect.Type, types *typeRegistry, naming NamingStrategy) *schema {
+	if naming == nil {
+		naming = Naming{}
+	}
+
+	key := schemaKey{Type: modelType, Types: types, Naming: naming}
+	if cached, ok := schemas.Load(key); ok {
+		return cached.(*schema)
+	}
+
+	s := parseSchema(modelType, types, naming)
+	cached, _ := schemas.LoadOrStore(key, s)
+
+	return cached.(*schema)
+}
+
+//parseSchema ...
+func parseSchema(modelType reflect.Type, types *typeRegistry, naming NamingStrategy) *schema {
+	s := &schema{Type: modelType, Table: tableName(modelType, naming), types: types, naming: naming}
+	s.parseFields(modelType, nil, "")
+
+	for _, field := range s.Fields {
+		if field.PrimaryKey {
+			s.PrimaryKeys = append(s.PrimaryKeys, field)
+		}
+	}
+
+	if len(s.PrimaryKeys) == 0 {
+		//an untagged integer Id or ID is the table's generated key, left out of inserts while zero
+		for _, field := range s.Fields {
+			if !field.Relation && (field.Column == "id" || strings.EqualFold(field.Name, "id")) {
+				field.PrimaryKey = true
+				field.AutoIncrement = isInteger(field.Type)
+				s.PrimaryKeys = append(s.PrimaryKeys, field)
+				break
+			}
+		}
+	}
+
+	return s
+}
+
+//parseFields adds the fields of a struct type, flattening anonymous and `cworm:"embedded"` structs
+//into the parent's columns.
+func (s *schema) parseFields(structType reflect.Type, index []int, prefix string) {
+	for i := 0; i < structType.NumField(); i++ {
+		structField := structType.Field(i)
+		if structField.PkgPath != "" {
+			continue
+		}
+
+		field := parseField(structField, s.naming)
+		if field.Ignore {
+			continue
+		}
+
+		field.Index = append(append([]int{}, index...), i)
+		field.Column = prefix + field.Column
+
+		if (structField.Anonymous || field.Embedded) && structField.Type.Kind() == reflect.Struct && !isValueType(structField.Type) && !s.types.Has(structField.Type) {
+			s.parseFields(structField.Type, field.Index, prefix+field.Prefix)
+			continue
+		}
+
+		if !field.JSON && isRelation(structField.Type, s.types) {
+			field.Relation = true
+			field.RelationType = relationType(structField.Type)
+			field.JSONObject = structField.Tag.Get("json_object")
+			field.ForeignKey = structField.Tag.Get("foreign_key")
+		}
+
+		s.Fields = append(s.Fields, field)
+	}
+}
+
+//FieldByName ...
+func (s *schema) FieldByName(name string) *modelField {
+	for _, field := range s.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+
+	return nil
+}
+
+//FieldByColumn finds a column field by column or field name.
+func (s *schema) FieldByColumn(column string) *modelField {
+	for _, field := range s.Fields {
+		if !field.Relation && (field.Column == column || field.Name == column) {
+			return field
+		}
+	}
+
+	return nil
+}
+
+//RelationTo returns the relation field holding models of the given type.
+func (s *schema) RelationTo(modelType reflect.Type) *modelField {
+	for _, field := range s.Fields {
+		if field.Relation && field.RelationType == modelType {
+			return field
+		}
+	}
+
+	return nil
+}
+
+//isInteger reports whether a field type is a signed or unsigned integer.
+func isInteger(fieldType reflect.Type) bool {
+	return isSigned(fieldType.Kind()) || isUnsigned(fieldType.Kind())
+}
+
+//isRelation reports whether a field type holds other models rather than a column value.
+func isRelation(fieldType reflect.Type, types *typeRegistry) bool {
+	if isValueType(fieldType) || types.Has(fieldType) {
+		return false
+	}
+
+	switch fieldType.Kind() {
+	case reflect.Struct:
+		return true
+	case reflect.Ptr:
+		return isRelation(fieldType.Elem(), types)
+	case reflect.Slice:
+		return fieldType.Elem().Kind() != reflect.Uint8
+	}
+
+	return false
+}
+
+//relationType strips pointers and slices from a relation field type, e.g. *[]Tag => Tag.
+func relationType(fieldType reflect.Type) reflect.Type {
+	for fieldType.Kind() == reflect.Ptr || fieldType.Kind() == reflect.Slice {
+		fieldType = fieldType.Elem()
+	}
+
+	return fieldType
+}
@@ -0,0 +1,114 @@
+package cworm
+
+import (
+	"testing"
+	"time"
+)
+
+type benchAuthor struct {
+	Id   int
+	Name string
+}
+
+type benchPost struct {
+	Id          int
+	Title       string `cworm:"column:post_title"`
+	Body        string
+	Views       uint
+	Published   bool
+	PublishedAt *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Author      benchAuthor `foreign_key:"author_id"`
+}
+
+type acronymPost struct {
+	ID    int
+	Title string
+}
+
+//clearSchemas empties the schema cache, to compare against reflecting on every call.
+func clearSchemas() {
+	schemas.Range(func(key, _ interface{}) bool {
+		schemas.Delete(key)
+		return true
+	})
+}
+
+func TestUntaggedPrimaryKey(t *testing.T) {
+	db := &DB{}
+
+	statements, err := db.ToSQL(func(db *DB) error {
+		if _, err := db.Insert(&acronymPost{Title: "Hello"}); err != nil {
+			return err
+		}
+
+		return db.Save(&acronymPost{ID: 3, Title: "Hello"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"INSERT INTO acronym_posts (title) VALUES (?)",
+		"UPDATE acronym_posts SET title=? WHERE acronym_posts.id=?",
+	}
+	if len(statements) != len(want) {
+		t.Fatalf("got %d statements, want %d", len(statements), len(want))
+	}
+	for i, statement := range statements {
+		if statement.SQL != want[i] {
+			t.Errorf("got %q, want %q", statement.SQL, want[i])
+		}
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	for _, cached := range []bool{true, false} {
+		name := "cached"
+		if !cached {
+			name = "uncached"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			db := &DB{DryRun: true}
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				if !cached {
+					clearSchemas()
+				}
+
+				if _, err := db.Join(&benchAuthor{}).Where("views", ">", 10).Get(&benchPost{}); err != nil {
+					b.Fatal(err)
+				}
+				db.Statements = db.Statements[:0]
+			}
+		})
+	}
+}
+
+func BenchmarkInsert(b *testing.B) {
+	for _, cached := range []bool{true, false} {
+		name := "cached"
+		if !cached {
+			name = "uncached"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			db := &DB{DryRun: true}
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				if !cached {
+					clearSchemas()
+				}
+
+				if _, err := db.Insert(&benchPost{Title: "Hello", Body: "World", Views: 1}); err != nil {
+					b.Fatal(err)
+				}
+				db.Statements = db.Statements[:0]
+			}
+		})
+	}
+}
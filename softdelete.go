@@ -0,0 +1,97 @@
+package cworm
+
+import (
+	"reflect"
+	"time"
+)
+
+//softDeleteField is the field marking the rows of a model as deleted instead of removing them.
+//It can be *time.Time, sql.NullTime or an integer unix timestamp, rows that are not deleted hold NULL or 0.
+//
+//	type Post struct {
+//		cworm.Model
+//		DeletedAt *time.Time
+//	}
+const softDeleteField = "DeletedAt"
+
+//trashed selects which rows of a soft deletable model a query sees.
+type trashed int
+
+const (
+	withoutTrashed trashed = iota
+	withTrashed
+	onlyTrashed
+)
+
+//softDelete returns the DeletedAt field of a model, if it has one of a supported type.
+func softDelete(modelSchema *schema) *modelField {
+	if modelSchema == nil {
+		return nil
+	}
+
+	field := modelSchema.FieldByName(softDeleteField)
+	if field == nil || field.Relation {
+		return nil
+	}
+
+	if field.Type == reflect.PtrTo(timeType) || field.Type == nullTimeType || isUnixTimestamp(field.Type) {
+		return field
+	}
+
+	return nil
+}
+
+//isUnixTimestamp reports whether a field type holds a time as an integer unix timestamp.
+func isUnixTimestamp(fieldType reflect.Type) bool {
+	switch fieldType.Kind() {
+	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+
+	return false
+}
+
+//trashedCondition returns the condition hiding, or keeping only, the soft deleted rows of a model.
+func trashedCondition(modelSchema *schema, scope trashed) string {
+	field := softDelete(modelSchema)
+	if field == nil || scope == withTrashed {
+		return ""
+	}
+
+	column := modelSchema.Table + "." + field.Column
+	if isUnixTimestamp(field.Type) {
+		if scope == onlyTrashed {
+			return column + "<>0"
+		}
+		return column + "=0"
+	}
+
+	if scope == onlyTrashed {
+		return column + " IS NOT NULL"
+	}
+	return column + " IS NULL"
+}
+
+//scopeTrashed adds the trashed condition of the query's model to its WHERE clause.
+func (query *Query) scopeTrashed() {
+	condition := trashedCondition(query.schema, query.trashed)
+	if condition == "" {
+		return
+	}
+
+	if query.Where == "" {
+		query.Where = " WHERE " + condition
+	} else {
+		query.Where += " AND " + condition
+	}
+}
+
+//deletedValue returns the DeletedAt value marking a row as deleted at now, or as not deleted when now is zero.
+func deletedValue(field *modelField, now time.Time) reflect.Value {
+	value := reflect.New(field.Type).Elem()
+	if !now.IsZero() {
+		setTimestamp(value, now)
+	}
+
+	return value
+}
@@ -0,0 +1,29 @@
+package cworm
+
+import (
+	"testing"
+	"time"
+)
+
+type trashablePost struct {
+	Id        int
+	Title     string
+	DeletedAt *time.Time
+}
+
+func TestSaveLeavesDeletedAt(t *testing.T) {
+	db := &DB{}
+	deletedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	statements, err := db.ToSQL(func(db *DB) error {
+		return db.Save(&trashablePost{Id: 3, Title: "Hello", DeletedAt: &deletedAt})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "UPDATE trashable_posts SET title=? WHERE trashable_posts.id=?"
+	if len(statements) != 1 || statements[0].SQL != want {
+		t.Errorf("got %#v, want %q", statements, want)
+	}
+}
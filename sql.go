@@ -1,9 +1,8 @@
 package cworm
 
 import (
-	"errors"
 	"fmt"
-	"reflect"
+	"strings"
 )
 
 //Where ...
@@ -13,6 +12,14 @@ type Where struct {
 	Value    interface{}
 }
 
+//WhereJSON ...
+type WhereJSON struct {
+	Column   string
+	Path     []string
+	Operator string
+	Value    interface{}
+}
+
 //TODO:
 // Finished getting mapper working, need to fill struct now w/ query
 // [ ] Add/fix JOIN order, currently alphabetical and not specified order.
@@ -20,11 +27,13 @@ type Where struct {
 
 //ResetQuery ...
 func (db *DB) ResetQuery() {
-	db.Query = Query{}
+	db.Query = Query{db: db}
 }
 
 //Select ...
 func (db *DB) Select(columns ...string) *DB {
+	db.Query.selected = append(db.Query.selected, columns...)
+
 	for _, column := range columns {
 		if db.Query.Select == "" {
 			db.Query.Select = fmt.Sprintf("SELECT %s", column)
@@ -36,6 +45,50 @@ func (db *DB) Select(columns ...string) *DB {
 	return db
 }
 
+//Model sets the model whose table UpdateColumns and DeleteWhere run on.
+func (db *DB) Model(Model interface{}) *DB {
+	db.Query.db = db
+	db.Error(db.Query.setModel(Model))
+
+	return db
+}
+
+//AllowGlobal lets UpdateColumns and DeleteWhere run without conditions, on every row of the table.
+func (db *DB) AllowGlobal() *DB {
+	db.Query.global = true
+
+	return db
+}
+
+//WithTrashed includes soft deleted rows in the results.
+func (db *DB) WithTrashed() *DB {
+	db.Query.trashed = withTrashed
+
+	return db
+}
+
+//OnlyTrashed restricts the results to soft deleted rows.
+func (db *DB) OnlyTrashed() *DB {
+	db.Query.trashed = onlyTrashed
+
+	return db
+}
+
+//Refresh reads the column changed by the next Increment or Decrement back into the model, which
+//needs its primary key, so it can't be used with conditions.
+func (db *DB) Refresh() *DB {
+	db.Query.refresh = true
+
+	return db
+}
+
+//Omit leaves columns out of the next Save.
+func (db *DB) Omit(columns ...string) *DB {
+	db.Query.omitted = append(db.Query.omitted, columns...)
+
+	return db
+}
+
 //Join ...
 func (db *DB) Join(models ...interface{}) *DB {
 	for _, model := range models {
@@ -52,6 +105,15 @@ func (db *DB) Where(column string, operator string, value interface{}) *DB {
 	return db
 }
 
+//WhereJSON compares a key inside a JSON column. The first segment of the dotted path
+//names the column, e.g. "meta.seo.title" reads $.seo.title from the meta column.
+func (db *DB) WhereJSON(path string, operator string, value interface{}) *DB {
+	segments := strings.Split(path, ".")
+	db.Query.Conditions = append(db.Query.Conditions, WhereJSON{Column: segments[0], Path: segments[1:], Operator: operator, Value: value})
+
+	return db
+}
+
 //GroupBy ...
 func (db *DB) GroupBy(columns ...string) *DB {
 	for _, column := range columns {
@@ -89,18 +151,3 @@ func (db *DB) Offset(offset int) *DB {
 
 	return db
 }
-
-//getTableName ...
-func getTableName(Model interface{}) (string, error) {
-	modelStruct := reflect.TypeOf(Model)
-
-	if modelStruct.Kind() == reflect.Ptr {
-		modelStruct = modelStruct.Elem()
-	}
-
-	if modelStruct.Kind() != reflect.Struct {
-		return "", errors.New("Model given is not a struct")
-	}
-
-	return pluralizeString(snakeCase(modelStruct.Name())), nil
-}
@@ -0,0 +1,84 @@
+package cworm
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+//Tabler is implemented by models that override their table name.
+type Tabler interface {
+	TableName() string
+}
+
+//parseField reads the `cworm` tag of a struct field.
+func parseField(field reflect.StructField, naming NamingStrategy) *modelField {
+	f := &modelField{Name: field.Name, Index: field.Index, Type: field.Type, Column: naming.ColumnName(field.Name)}
+
+	f.JSONKey = strings.Split(field.Tag.Get("json"), ",")[0]
+	if f.JSONKey == "" || f.JSONKey == "-" {
+		f.JSONKey = f.Column
+	}
+
+	tag := strings.TrimSpace(field.Tag.Get("cworm"))
+	if tag == "-" {
+		f.Ignore = true
+		return f
+	}
+
+	for _, option := range strings.Split(tag, ";") {
+		key, value := option, ""
+		if i := strings.Index(option, ":"); i >= 0 {
+			key, value = option[:i], strings.TrimSpace(option[i+1:])
+		}
+
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "column":
+			if value != "" {
+				f.Column = value
+			}
+		case "primarykey":
+			f.PrimaryKey = true
+		case "autoincrement":
+			f.AutoIncrement = true
+		case "readonly":
+			f.Readonly = true
+		case "version":
+			f.Version = true
+		case "json":
+			f.JSON = true
+		case "embedded":
+			f.Embedded = true
+		case "prefix":
+			f.Prefix = value
+		case "required":
+			f.Required = true
+		case "email":
+			f.Email = true
+		case "min":
+			if n, err := strconv.ParseFloat(value, 64); err == nil {
+				f.Min = &n
+			}
+		case "max":
+			if n, err := strconv.ParseFloat(value, 64); err == nil {
+				f.Max = &n
+			}
+		}
+	}
+
+	return f
+}
+
+//tableName returns the table for a model type, honoring the Tabler interface.
+func tableName(modelType reflect.Type, naming NamingStrategy) string {
+	if tabler, ok := reflect.New(modelType).Interface().(Tabler); ok {
+		return tabler.TableName()
+	}
+
+	return naming.TableName(modelType.Name())
+}
+
+//isZeroValue reports whether v is nil or the zero value of its type.
+func isZeroValue(v interface{}) bool {
+	return v == nil || reflect.ValueOf(v).IsZero()
+}
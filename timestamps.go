@@ -0,0 +1,115 @@
+package cworm
+
+import (
+	"database/sql"
+	"reflect"
+	"time"
+)
+
+//Timestamps configures the fields filled automatically with the current time. CreatedAt is
+//set on insert and UpdatedAt on insert and update, when the model has them. Fields can be
+//time.Time, *time.Time, sql.NullTime or an integer holding a unix timestamp.
+type Timestamps struct {
+	CreatedAt string
+	UpdatedAt string
+	Disabled  bool
+}
+
+//timestamps returns the timestamp settings of the query's connection.
+func (query *Query) timestamps() Timestamps {
+	var timestamps Timestamps
+	if query.db != nil {
+		timestamps = query.db.Timestamps
+	}
+
+	if timestamps.CreatedAt == "" {
+		timestamps.CreatedAt = "CreatedAt"
+	}
+	if timestamps.UpdatedAt == "" {
+		timestamps.UpdatedAt = "UpdatedAt"
+	}
+
+	return timestamps
+}
+
+//now returns the current time from the connection's Clock.
+func (query *Query) now() time.Time {
+	if query.db != nil && query.db.Clock != nil {
+		return query.db.Clock()
+	}
+
+	return time.Now()
+}
+
+//isTimestamp reports whether a field is one of the automatic timestamps.
+func (query *Query) isTimestamp(field *modelField) bool {
+	timestamps := query.timestamps()
+
+	return field.Name == timestamps.CreatedAt || field.Name == timestamps.UpdatedAt
+}
+
+//touchInsert fills the unset timestamps of a model about to be inserted.
+func (query *Query) touchInsert(model reflect.Value, now time.Time) {
+	timestamps := query.timestamps()
+	if timestamps.Disabled || !model.CanSet() {
+		return
+	}
+
+	modelSchema := query.getSchema(model.Type())
+	for _, name := range []string{timestamps.CreatedAt, timestamps.UpdatedAt} {
+		if field := modelSchema.FieldByName(name); field != nil && model.FieldByIndex(field.Index).IsZero() {
+			setTimestamp(model.FieldByIndex(field.Index), now)
+		}
+	}
+}
+
+//touchUpdate returns the UpdatedAt field of a model about to be updated and its new value,
+//setting it on the model when it can be.
+func (query *Query) touchUpdate(model reflect.Value, now time.Time) (*modelField, interface{}, bool) {
+	timestamps := query.timestamps()
+	if timestamps.Disabled || query.schema == nil {
+		return nil, nil, false
+	}
+
+	field := query.schema.FieldByName(timestamps.UpdatedAt)
+	if field == nil {
+		return nil, nil, false
+	}
+
+	value := reflect.New(field.Type).Elem()
+	if !setTimestamp(value, now) {
+		return nil, nil, false
+	}
+
+	if model.CanSet() {
+		model.FieldByIndex(field.Index).Set(value)
+	}
+
+	return field, fieldValue(value), true
+}
+
+//setTimestamp sets a time or unix timestamp field, reporting whether its type is supported.
+func setTimestamp(structField reflect.Value, now time.Time) bool {
+	switch structField.Type() {
+	case timeType:
+		structField.Set(reflect.ValueOf(now))
+		return true
+	case reflect.PtrTo(timeType):
+		structField.Set(reflect.ValueOf(&now))
+		return true
+	case nullTimeType:
+		structField.Set(reflect.ValueOf(sql.NullTime{Time: now, Valid: true}))
+		return true
+	}
+
+	switch structField.Kind() {
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		structField.SetInt(now.Unix())
+		return true
+	case reflect.Uint, reflect.Uint32, reflect.Uint64:
+		structField.SetUint(uint64(now.Unix()))
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,108 @@
+package cworm
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type stampedPost struct {
+	Id        int
+	Title     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type unixPost struct {
+	Id        int
+	Title     string
+	CreatedAt int64
+	UpdatedAt uint32
+}
+
+func TestClockTimestamps(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	tests := []struct {
+		name string
+		fn   func(db *DB) error
+		sql  string
+		args []interface{}
+	}{
+		{
+			name: "insert time.Time",
+			fn: func(db *DB) error {
+				_, err := db.Insert(&stampedPost{Title: "Hello"})
+				return err
+			},
+			sql:  "INSERT INTO stamped_posts (title,created_at,updated_at) VALUES (?,?,?)",
+			args: []interface{}{"Hello", now, now},
+		},
+		{
+			name: "save time.Time",
+			fn: func(db *DB) error {
+				return db.Save(&stampedPost{Id: 1, Title: "Hello"})
+			},
+			sql:  "UPDATE stamped_posts SET title=?,updated_at=? WHERE stamped_posts.id=?",
+			args: []interface{}{"Hello", now, 1},
+		},
+		{
+			name: "insert unix",
+			fn: func(db *DB) error {
+				_, err := db.Insert(&unixPost{Title: "Hello"})
+				return err
+			},
+			sql:  "INSERT INTO unix_posts (title,created_at,updated_at) VALUES (?,?,?)",
+			args: []interface{}{"Hello", now.Unix(), uint32(now.Unix())},
+		},
+		{
+			name: "save unix",
+			fn: func(db *DB) error {
+				return db.Save(&unixPost{Id: 1, Title: "Hello"})
+			},
+			sql:  "UPDATE unix_posts SET title=?,updated_at=? WHERE unix_posts.id=?",
+			args: []interface{}{"Hello", uint32(now.Unix()), 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := &DB{Clock: clock}
+
+			statements, err := db.ToSQL(test.fn)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(statements) != 1 || statements[0].SQL != test.sql || !reflect.DeepEqual(statements[0].Args, test.args) {
+				t.Errorf("got %#v, want %q with %#v", statements, test.sql, test.args)
+			}
+		})
+	}
+}
+
+func TestClockSetsModel(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	db := &DB{Clock: func() time.Time { return now }}
+
+	stamped, unix := stampedPost{Title: "Hello"}, unixPost{Title: "Hello"}
+	_, err := db.ToSQL(func(db *DB) error {
+		if _, err := db.Insert(&stamped); err != nil {
+			return err
+		}
+
+		_, err := db.Insert(&unix)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !stamped.CreatedAt.Equal(now) || !stamped.UpdatedAt.Equal(now) {
+		t.Errorf("got %s and %s, want both %s", stamped.CreatedAt, stamped.UpdatedAt, now)
+	}
+	if unix.CreatedAt != now.Unix() || unix.UpdatedAt != uint32(now.Unix()) {
+		t.Errorf("got %d and %d, want both %d", unix.CreatedAt, unix.UpdatedAt, now.Unix())
+	}
+}
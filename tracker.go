@@ -0,0 +1,94 @@
+package cworm
+
+import (
+	"database/sql/driver"
+	"reflect"
+)
+
+//Tracker can be embedded in a model so Save only writes the columns changed since the model
+//was loaded, inserted or saved.
+//
+//	type Post struct {
+//		cworm.Tracker
+//		Id    int
+//		Title string
+//	}
+type Tracker struct {
+	snapshot map[string]interface{}
+}
+
+//tracked is implemented by models embedding Tracker.
+type tracked interface {
+	setSnapshot(snapshot map[string]interface{})
+	getSnapshot() map[string]interface{}
+}
+
+func (tracker *Tracker) setSnapshot(snapshot map[string]interface{}) {
+	tracker.snapshot = snapshot
+}
+
+func (tracker *Tracker) getSnapshot() map[string]interface{} {
+	return tracker.snapshot
+}
+
+//trackerOf returns the Tracker of a model if it embeds one.
+func trackerOf(model reflect.Value) (tracked, bool) {
+	if !model.CanAddr() {
+		return nil, false
+	}
+
+	tracker, ok := model.Addr().Interface().(tracked)
+	return tracker, ok
+}
+
+//takeSnapshot records the column values of a tracked model.
+func (query *Query) takeSnapshot(model reflect.Value) {
+	tracker, ok := trackerOf(model)
+	if !ok {
+		return
+	}
+
+	snapshot := make(map[string]interface{})
+	for _, field := range query.getSchema(model.Type()).Fields {
+		if field.Relation {
+			continue
+		}
+
+		value, err := query.columnValue(field, model.FieldByIndex(field.Index))
+		if err != nil {
+			continue
+		}
+
+		snapshot[field.Column] = snapshotValue(value)
+	}
+
+	tracker.setSnapshot(snapshot)
+}
+
+//isChanged reports whether a column differs from the snapshot of a tracked model.
+//Untracked models and models without a snapshot always count as changed.
+func (query *Query) isChanged(model reflect.Value, field *modelField, value interface{}) bool {
+	tracker, ok := trackerOf(model)
+	if !ok || tracker.getSnapshot() == nil {
+		return true
+	}
+
+	original, ok := tracker.getSnapshot()[field.Column]
+
+	return !ok || !reflect.DeepEqual(original, snapshotValue(value))
+}
+
+//snapshotValue copies a column value so later changes to the model don't alter it.
+func snapshotValue(value interface{}) interface{} {
+	if valuer, ok := value.(driver.Valuer); ok {
+		if v, err := valuer.Value(); err == nil {
+			value = v
+		}
+	}
+
+	if bytes, ok := value.([]byte); ok {
+		return append([]byte(nil), bytes...)
+	}
+
+	return value
+}
@@ -0,0 +1,58 @@
+package cworm
+
+import "time"
+
+//Transaction runs fn inside a database transaction, committing when it returns nil and
+//rolling back when it returns an error or panics. Calls nested in an open transaction
+//reuse it, and in DryRun mode fn runs without one.
+//
+//Transactions failing with a deadlock or serialization failure are run again as configured
+//by the DB's Retry policy, or by the policy given, so fn must be safe to run more than once.
+func (db *DB) Transaction(fn func(tx *DB) error, policy ...RetryPolicy) error {
+	if db.Tx != nil || db.DryRun {
+		return fn(db)
+	}
+
+	retry := db.Retry
+	if len(policy) > 0 {
+		retry = policy[0]
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := db.transaction(fn)
+		if err == nil || attempt >= retry.MaxAttempts || !isRetryable(err) {
+			return err
+		}
+
+		delay := retry.backoff(attempt)
+		if retry.OnRetry != nil {
+			retry.OnRetry(attempt, err, delay)
+		}
+		time.Sleep(delay)
+	}
+}
+
+//transaction runs fn once inside a new transaction.
+func (db *DB) transaction(fn func(tx *DB) error) (err error) {
+	sqlTx, err := db.Begin()
+	if err != nil {
+		return db.classify(err)
+	}
+
+	tx := db.session()
+	tx.Tx = sqlTx
+
+	defer func() {
+		if r := recover(); r != nil {
+			sqlTx.Rollback()
+			panic(r)
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		sqlTx.Rollback()
+		return db.classify(err)
+	}
+
+	return db.classify(sqlTx.Commit())
+}
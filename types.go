@@ -0,0 +1,160 @@
+package cworm
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	nullTimeType = reflect.TypeOf(sql.NullTime{})
+	scannerType  = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	valuerType   = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+//isValueType reports whether a struct type is stored in a single column, such as
+//time.Time, sql.NullString or any sql.Scanner/driver.Valuer, rather than being a relation.
+func isValueType(fieldType reflect.Type) bool {
+	return fieldType == timeType ||
+		fieldType.Implements(valuerType) ||
+		reflect.PtrTo(fieldType).Implements(valuerType) ||
+		reflect.PtrTo(fieldType).Implements(scannerType)
+}
+
+//fieldValue returns the value written for a struct field. Nil pointers are written as NULL,
+//every other value, including zero values, is written as is.
+func fieldValue(structField reflect.Value) interface{} {
+	if structField.Kind() == reflect.Ptr && !structField.Type().Implements(valuerType) {
+		if structField.IsNil() {
+			return nil
+		}
+
+		return fieldValue(structField.Elem())
+	}
+
+	if structField.CanAddr() && !structField.Type().Implements(valuerType) && structField.Addr().Type().Implements(valuerType) {
+		return structField.Addr().Interface()
+	}
+
+	return structField.Interface()
+}
+
+//rawValue renders a driver value as raw column bytes, keeping NULL as nil and
+//empty values as non-nil empty slices.
+func rawValue(value interface{}) sql.RawBytes {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return append(sql.RawBytes{}, v...)
+	case string:
+		return append(sql.RawBytes{}, v...)
+	case time.Time:
+		return v.AppendFormat(sql.RawBytes{}, time.RFC3339Nano)
+	case int64:
+		return strconv.AppendInt(sql.RawBytes{}, v, 10)
+	case float64:
+		return strconv.AppendFloat(sql.RawBytes{}, v, 'g', -1, 64)
+	case bool:
+		if v {
+			return sql.RawBytes("1")
+		}
+		return sql.RawBytes("0")
+	}
+
+	return sql.RawBytes(fmt.Sprint(value))
+}
+
+//scanValue converts a raw column value into the struct field. NULL is only accepted by
+//pointers, []byte and sql.Scanner types such as sql.NullString.
+func scanValue(structField reflect.Value, val []byte, dialect Dialect) error {
+	if structField.Kind() == reflect.Ptr {
+		if val == nil {
+			structField.Set(reflect.Zero(structField.Type()))
+			return nil
+		}
+
+		ptr := reflect.New(structField.Type().Elem())
+		if err := scanValue(ptr.Elem(), val, dialect); err != nil {
+			return err
+		}
+
+		structField.Set(ptr)
+		return nil
+	}
+
+	if scanner, ok := structField.Addr().Interface().(sql.Scanner); ok {
+		if val == nil {
+			return scanner.Scan(nil)
+		}
+
+		if structField.Type() == nullTimeType {
+			t, err := dialect.ParseTime(string(val))
+			if err != nil {
+				return err
+			}
+			return scanner.Scan(t)
+		}
+
+		return scanner.Scan(append([]byte(nil), val...))
+	}
+
+	if val == nil {
+		if structField.Kind() == reflect.Slice && structField.Type().Elem().Kind() == reflect.Uint8 {
+			structField.Set(reflect.Zero(structField.Type()))
+			return nil
+		}
+
+		return fmt.Errorf("is NULL but %s is not nullable, use a pointer or sql.Null* type", structField.Type())
+	}
+
+	if structField.Type() == timeType {
+		t, err := dialect.ParseTime(string(val))
+		if err != nil {
+			return err
+		}
+
+		structField.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	str := string(val)
+
+	switch structField.Kind() {
+	case reflect.String:
+		structField.SetString(str)
+	case reflect.Bool:
+		structField.SetBool(str == "1" || str == "\x01" || str == "t" || str == "true" || str == "TRUE")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(str, 10, structField.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("as %s: %w", structField.Kind(), err)
+		}
+		structField.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(str, 10, structField.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("as %s: %w", structField.Kind(), err)
+		}
+		structField.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(str, structField.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("as %s: %w", structField.Kind(), err)
+		}
+		structField.SetFloat(n)
+	case reflect.Slice:
+		if structField.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("Unsupported type in Scan: %s", structField.Type())
+		}
+		structField.SetBytes(append([]byte{}, val...))
+	default:
+		return fmt.Errorf("Unsupported type in Scan: %s", structField.Type())
+	}
+
+	return nil
+}
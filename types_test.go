@@ -0,0 +1,146 @@
+package cworm
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+//pointerValuer implements driver.Valuer on its pointer only.
+type pointerValuer struct {
+	Text string
+}
+
+func (v *pointerValuer) Value() (driver.Value, error) {
+	return v.Text, nil
+}
+
+func TestScanValue(t *testing.T) {
+	five, empty := 5, ""
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		field interface{}
+		raw   []byte
+		want  interface{}
+		err   string
+	}{
+		{name: "NULL into pointer", field: &five, raw: nil, want: (*int)(nil)},
+		{name: "value into pointer", field: (*int)(nil), raw: []byte("5"), want: &five},
+		{name: "empty string into pointer", field: (*string)(nil), raw: []byte{}, want: &empty},
+		{name: "NULL into sql.NullString", field: sql.NullString{String: "x", Valid: true}, raw: nil, want: sql.NullString{}},
+		{name: "empty string into sql.NullString", field: sql.NullString{}, raw: []byte{}, want: sql.NullString{Valid: true}},
+		{name: "NULL into sql.NullInt64", field: sql.NullInt64{Int64: 1, Valid: true}, raw: nil, want: sql.NullInt64{}},
+		{name: "value into sql.NullInt64", field: sql.NullInt64{}, raw: []byte("7"), want: sql.NullInt64{Int64: 7, Valid: true}},
+		{name: "NULL into sql.NullTime", field: sql.NullTime{Time: at, Valid: true}, raw: nil, want: sql.NullTime{}},
+		{name: "value into sql.NullTime", field: sql.NullTime{}, raw: []byte("2024-01-02 03:04:05"), want: sql.NullTime{Time: at, Valid: true}},
+		{name: "NULL into []byte", field: []byte("x"), raw: nil, want: []byte(nil)},
+		{name: "empty into []byte", field: []byte(nil), raw: []byte{}, want: []byte{}},
+		{name: "empty string", field: "x", raw: []byte{}, want: ""},
+		{name: "zero int", field: 1, raw: []byte("0"), want: 0},
+		{name: "uint", field: uint8(0), raw: []byte("255"), want: uint8(255)},
+		{name: "bool", field: false, raw: []byte("1"), want: true},
+		{name: "float", field: 0.0, raw: []byte("1.5"), want: 1.5},
+		{name: "time", field: time.Time{}, raw: []byte("2024-01-02 03:04:05"), want: at},
+		{name: "NULL into string", field: "", raw: nil, err: "is NULL but string is not nullable"},
+		{name: "NULL into int", field: 0, raw: nil, err: "is NULL but int is not nullable"},
+		{name: "NULL into time", field: time.Time{}, raw: nil, err: "is NULL but time.Time is not nullable"},
+		{name: "uint overflow", field: uint8(0), raw: []byte("256"), err: "as uint8"},
+		{name: "int from text", field: 0, raw: []byte("abc"), err: "as int"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			field := reflect.New(reflect.TypeOf(test.field)).Elem()
+			field.Set(reflect.ValueOf(test.field))
+
+			err := scanValue(field, test.raw, mysqlDialect{})
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("got error %v, want %q", err, test.err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := field.Interface(); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFieldValue(t *testing.T) {
+	empty := ""
+
+	tests := []struct {
+		name  string
+		field interface{}
+		want  interface{}
+	}{
+		{name: "nil pointer is NULL", field: (*string)(nil), want: nil},
+		{name: "pointer to empty string", field: &empty, want: ""},
+		{name: "empty string", field: "", want: ""},
+		{name: "zero int", field: 0, want: 0},
+		{name: "false", field: false, want: false},
+		{name: "invalid sql.NullString", field: sql.NullString{}, want: sql.NullString{}},
+		{name: "valid sql.NullInt64", field: sql.NullInt64{Int64: 3, Valid: true}, want: sql.NullInt64{Int64: 3, Valid: true}},
+		{name: "nil []byte", field: []byte(nil), want: []byte(nil)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := fieldValue(reflect.ValueOf(test.field)); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %#v, want %#v", got, test.want)
+			}
+		})
+	}
+
+	t.Run("pointer receiver Valuer", func(t *testing.T) {
+		field := reflect.New(reflect.TypeOf(pointerValuer{})).Elem()
+		field.Set(reflect.ValueOf(pointerValuer{Text: "x"}))
+
+		valuer, ok := fieldValue(field).(driver.Valuer)
+		if !ok {
+			t.Fatalf("got %T, want a driver.Valuer", fieldValue(field))
+		}
+		if value, _ := valuer.Value(); value != "x" {
+			t.Errorf("got %#v, want %q", value, "x")
+		}
+	})
+}
+
+func TestRawValue(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  sql.RawBytes
+	}{
+		{name: "NULL", value: nil, want: nil},
+		{name: "empty string", value: "", want: sql.RawBytes{}},
+		{name: "empty bytes", value: []byte{}, want: sql.RawBytes{}},
+		{name: "string", value: "abc", want: sql.RawBytes("abc")},
+		{name: "int64", value: int64(-4), want: sql.RawBytes("-4")},
+		{name: "float64", value: 1.25, want: sql.RawBytes("1.25")},
+		{name: "true", value: true, want: sql.RawBytes("1")},
+		{name: "false", value: false, want: sql.RawBytes("0")},
+		{name: "time", value: at, want: sql.RawBytes("2024-01-02T03:04:05Z")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := rawValue(test.value)
+			if (got == nil) != (test.want == nil) || string(got) != string(test.want) {
+				t.Errorf("got %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
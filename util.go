@@ -2,30 +2,96 @@ package cworm
 
 import (
 	"strings"
+	"unicode"
 )
 
+//irregularPlurals ...
+var irregularPlurals = map[string]string{
+	"person": "people",
+	"man":    "men",
+	"woman":  "women",
+	"child":  "children",
+	"mouse":  "mice",
+	"goose":  "geese",
+	"tooth":  "teeth",
+	"foot":   "feet",
+	"ox":     "oxen",
+	"leaf":   "leaves",
+	"life":   "lives",
+	"knife":  "knives",
+	"wife":   "wives",
+	"half":   "halves",
+	"hero":   "heroes",
+	"potato": "potatoes",
+	"tomato": "tomatoes",
+	"quiz":   "quizzes",
+}
+
+//uncountables are the same in singular and plural.
+var uncountables = map[string]bool{
+	"equipment":   true,
+	"information": true,
+	"metadata":    true,
+	"money":       true,
+	"news":        true,
+	"series":      true,
+	"sheep":       true,
+	"species":     true,
+	"fish":        true,
+	"deer":        true,
+}
+
+//pluralizeString pluralizes the last word of a snake_case name, e.g. blog_category => blog_categories.
 func pluralizeString(str string) string {
-	if strings.HasSuffix(str, "y") {
-		str = str[:len(str)-1] + "ie"
+	prefix, word := "", str
+	if i := strings.LastIndex(str, "_"); i >= 0 {
+		prefix, word = str[:i+1], str[i+1:]
+	}
+
+	lower := strings.ToLower(word)
+	if uncountables[lower] {
+		return str
+	}
+
+	if plural, ok := irregularPlurals[lower]; ok {
+		return prefix + word[:1] + plural[1:]
+	}
+
+	switch {
+	case strings.HasSuffix(lower, "y") && len(lower) > 1 && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		return prefix + word[:len(word)-1] + "ies"
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"), strings.HasSuffix(lower, "z"),
+		strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+		return prefix + word + "es"
 	}
-	return str + "s"
+
+	return prefix + word + "s"
 }
 
+//snakeCase converts a Go name to snake_case, keeping acronyms together, e.g. UserID => user_id, HTTPServer => http_server.
+//An acronym's plural s stays with it, e.g. UserIDs => user_ids.
 func snakeCase(name string) string {
-	str := make([]rune, 0)
-	first := true
-
-	for _, chr := range name {
-		if isUpper := 'A' <= chr && chr <= 'Z'; isUpper {
-			if first == true {
-				first = false
-			} else {
-				str = append(str, '_')
+	runes := []rune(name)
+	str := make([]rune, 0, len(runes)+4)
+
+	for i, chr := range runes {
+		if unicode.IsUpper(chr) {
+			if i > 0 {
+				prev := runes[i-1]
+				nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1]) && !isAcronymPlural(runes, i+1)
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+					str = append(str, '_')
+				}
 			}
-			chr -= ('A' - 'a')
+			chr = unicode.ToLower(chr)
 		}
 		str = append(str, chr)
 	}
 
 	return string(str)
 }
+
+//isAcronymPlural reports whether the rune at i is an s ending a word, following an acronym as in IDs.
+func isAcronymPlural(runes []rune, i int) bool {
+	return runes[i] == 's' && (i+1 == len(runes) || !unicode.IsLower(runes[i+1]))
+}
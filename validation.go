@@ -0,0 +1,168 @@
+package cworm
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+//Validator is implemented by models with validation beyond the rules of their `cworm` tags.
+//Returning ValidationErrors adds to the failing fields, any other error is listed as is.
+type Validator interface {
+	Validate() error
+}
+
+//FieldError is a field failing one of its validation rules.
+type FieldError struct {
+	Field   string
+	Rule    string
+	Message string
+}
+
+func (err FieldError) Error() string {
+	if err.Field == "" {
+		return err.Message
+	}
+
+	return err.Field + " " + err.Message
+}
+
+//ValidationErrors lists every field failing validation. Insert and Save return it before
+//building any SQL, check it with errors.As.
+//
+//	Title string `cworm:"required;max:191"`
+//	Email string `cworm:"email"`
+//	Age   int    `cworm:"min:13"`
+//
+//required rejects zero values, min and max bound the length of strings, slices and maps and
+//the value of numbers, and email checks the shape of non-empty strings.
+type ValidationErrors []FieldError
+
+func (errs ValidationErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+var emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+
+//validate checks the rules of the model's fields accepted by only, or of every field and the
+//model's Validate method when only is nil.
+func (query *Query) validate(model reflect.Value, only func(field *modelField) bool) error {
+	var errs ValidationErrors
+
+	for _, field := range query.getSchema(model.Type()).Fields {
+		if field.Relation || (only != nil && !only(field)) {
+			continue
+		}
+
+		errs = append(errs, validateField(field, model.FieldByIndex(field.Index))...)
+	}
+
+	if only == nil {
+		target := model.Interface()
+		if model.CanAddr() {
+			target = model.Addr().Interface()
+		}
+
+		if validator, ok := target.(Validator); ok {
+			if err := validator.Validate(); err != nil {
+				var validationErrs ValidationErrors
+				if errors.As(err, &validationErrs) {
+					errs = append(errs, validationErrs...)
+				} else {
+					errs = append(errs, FieldError{Rule: "validate", Message: err.Error()})
+				}
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+//validateSave validates the model written by a Save, only the fields left by Select and Omit,
+//or only the columns set by an Update applied to a copy of the model.
+func (query *Query) validateSave() error {
+	if query.updates == nil && len(query.selected) == 0 && len(query.omitted) == 0 {
+		return query.validate(query.Model, nil)
+	}
+
+	if query.updates == nil {
+		return query.validate(query.Model, query.isWritable)
+	}
+
+	model := reflect.New(query.Model.Type()).Elem()
+	model.Set(query.Model)
+	if err := assignValues(query.schema, model, query.updates); err != nil {
+		return err
+	}
+
+	return query.validate(model, query.isUpdating)
+}
+
+//validateField checks a field's value against the rules of its tag.
+func validateField(field *modelField, value reflect.Value) (errs []FieldError) {
+	if field.Required && value.IsZero() {
+		errs = append(errs, FieldError{Field: field.Name, Rule: "required", Message: "is required"})
+	}
+
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return errs
+		}
+		value = value.Elem()
+	}
+
+	size, sized := 0.0, true
+	switch value.Kind() {
+	case reflect.String:
+		size = float64(utf8.RuneCountInString(value.String()))
+	case reflect.Slice, reflect.Map, reflect.Array:
+		size = float64(value.Len())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		size = float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		size = float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		size = value.Float()
+	default:
+		sized = false
+	}
+
+	if sized && field.Min != nil && size < *field.Min {
+		errs = append(errs, FieldError{Field: field.Name, Rule: "min", Message: "must be at least " + formatBound(value, *field.Min)})
+	}
+	if sized && field.Max != nil && size > *field.Max {
+		errs = append(errs, FieldError{Field: field.Name, Rule: "max", Message: "must be at most " + formatBound(value, *field.Max)})
+	}
+
+	if field.Email && value.Kind() == reflect.String && value.String() != "" && !emailPattern.MatchString(value.String()) {
+		errs = append(errs, FieldError{Field: field.Name, Rule: "email", Message: "is not a valid email address"})
+	}
+
+	return errs
+}
+
+//formatBound renders a min or max rule, as a length for strings, slices and maps.
+func formatBound(value reflect.Value, bound float64) string {
+	n := strconv.FormatFloat(bound, 'f', -1, 64)
+
+	switch value.Kind() {
+	case reflect.String:
+		return n + " characters"
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return n + " items"
+	}
+
+	return n
+}